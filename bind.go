@@ -1,46 +1,160 @@
 package sfv
 
 import (
+	"encoding"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"time"
 )
 
-func bindItem(i Item, v reflect.Value) error {
+// The ranges of int and uint, which depend on the platform.
+const (
+	maxInt  = 1<<(strconv.IntSize-1) - 1
+	minInt  = -maxInt - 1
+	maxUint = 1<<strconv.IntSize - 1
+)
+
+// binder holds the options for binding parsed data to Go values.
+type binder struct {
+	disallowUnknownKeys bool
+	tokenStrings        bool
+}
+
+// checkUnknownParams returns an error if b disallows unknown keys, and p has a
+// parameter that isn't held by a field of the struct type t. If t is nil, all
+// parameters are unknown.
+func (b *binder) checkUnknownParams(p Params, t reflect.Type) error {
+	if !b.disallowUnknownKeys {
+		return nil
+	}
+
+	if t != nil {
+		if _, ok := restField(t); ok {
+			return nil
+		}
+	}
+
+	for _, k := range p.Keys {
+		if t == nil || !hasField(t, k) {
+			return &BindError{Path: paramPath(k), Err: ErrUnknownKey}
+		}
+	}
+
+	return nil
+}
+
+// wrapBindError returns err as a BindError, with path and field prepended to
+// its Path and Field. Errors are wrapped this way as they return up through
+// each level of the data being bound.
+func wrapBindError(err error, path, field string) error {
+	be, ok := err.(*BindError)
+	if !ok {
+		return &BindError{Path: path, Field: field, Err: err}
+	}
+
+	be.Path = path + be.Path
+	if field != "" && be.Field != "" {
+		be.Field = field + "." + be.Field
+	} else if field != "" {
+		be.Field = field
+	}
+
+	return be
+}
+
+// paramPath returns the part of a BindError path for the parameter k.
+func paramPath(k string) string {
+	return fmt.Sprintf(".params[%q]", k)
+}
+
+// indirect returns the value v points to, if v is a pointer, allocating a new
+// value if v is nil. Otherwise, it returns v.
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Ptr {
+		if v.IsNil() {
+			v.Set(reflect.New(v.Type().Elem()))
+		}
+
+		v = v.Elem()
+	}
+
+	return v
+}
+
+// fieldByIndex returns the field of the struct v at index, allocating any nil
+// embedded pointers along the way.
+func fieldByIndex(v reflect.Value, index []int) reflect.Value {
+	for i, x := range index {
+		if i > 0 {
+			v = indirect(v)
+		}
+
+		v = v.Field(x)
+	}
+
+	return v
+}
+
+// bindItem binds i to v. If v is a struct field, t is its tag.
+func bindItem(b *binder, i Item, v reflect.Value, t tag) error {
+	v = indirect(v)
+
+	if u, ok := v.Addr().Interface().(ItemUnmarshaler); ok {
+		return u.UnmarshalSFVItem(i)
+	}
+
+	// Empty interfaces hold the item as a Go primitive, alongside all of its
+	// parameters.
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(dynamicItem(i)))
+		return nil
+	}
+
 	// First, try to detect a primitive type. In this case, we'll just directly
 	// bind the bare item to v, and ignore the parameters.
-	switch v.Interface().(type) {
-	case bool, int, int8, int16, int32, int64, uint, uint8, uint16,
-		uint32, uint64, float32, float64, string, []byte:
-		return bindBareItem(i.BareItem, v.Addr().Interface())
+	//
+	// Switching on a pointer to v, rather than v itself, avoids copying v into
+	// an interface when it's a struct.
+	switch p := v.Addr().Interface(); p.(type) {
+	case *bool, *int, *int8, *int16, *int32, *int64, *uint, *uint8, *uint16,
+		*uint32, *uint64, *float32, *float64, *Decimal, *string, *Token, *DisplayString,
+		*[]byte, *time.Time, *time.Duration, encoding.TextUnmarshaler:
+		if err := b.checkUnknownParams(i.Params, nil); err != nil {
+			return err
+		}
+
+		return bindBareItem(b, i.BareItem, p, t)
 	}
 
 	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("cannot marshal item into %s", v.Type())
+		return fmt.Errorf("%w: cannot unmarshal item into %s", ErrTypeMismatch, v.Type())
 	}
 
-	if err := bindParams(i.Params, v); err != nil {
-		return fmt.Errorf("bind params: %w", err)
+	if err := bindParams(b, i.Params, v); err != nil {
+		return err
 	}
 
-	for j := 0; j < v.NumField(); j++ {
-		if _, ok := v.Type().Field(j).Tag.Lookup("sfv"); !ok {
-			if err := bindBareItem(i.BareItem, v.Field(j).Addr().Interface()); err != nil {
-				return fmt.Errorf("bind bare item: %w", err)
-			}
-
-			break
+	if f, ok := valueField(v.Type()); ok {
+		if err := bindBareItem(b, i.BareItem, indirect(fieldByIndex(v, f.index)).Addr().Interface(), f.tag); err != nil {
+			return wrapBindError(err, "", f.name)
 		}
 	}
 
 	return nil
 }
 
-func bindList(l List, v reflect.Value) error {
+func bindList(b *binder, l List, v reflect.Value) error {
+	if u, ok := v.Addr().Interface().(ListUnmarshaler); ok {
+		return u.UnmarshalSFVList(l)
+	}
+
 	if v.Kind() != reflect.Slice {
-		return fmt.Errorf("cannot marshal list into %s", v.Type())
+		return fmt.Errorf("%w: cannot unmarshal list into %s", ErrTypeMismatch, v.Type())
 	}
 
-	for _, m := range l {
+	for j, m := range l {
 		// In psuedo-code, what we want to do is:
 		//
 		// var T t
@@ -51,14 +165,8 @@ func bindList(l List, v reflect.Value) error {
 
 		item := reflect.New(v.Type().Elem())
 
-		if m.IsItem {
-			if err := bindItem(m.Item, item.Elem()); err != nil {
-				return err
-			}
-		} else {
-			if err := bindInnerList(m.InnerList, item.Elem()); err != nil {
-				return err
-			}
+		if err := bindMember(b, m, item.Elem(), tag{}); err != nil {
+			return wrapBindError(err, fmt.Sprintf("[%d]", j), "")
 		}
 
 		v.Set(reflect.Append(v, item.Elem()))
@@ -67,36 +175,45 @@ func bindList(l List, v reflect.Value) error {
 	return nil
 }
 
-func bindInnerList(l InnerList, v reflect.Value) error {
-	// If v is a struct, then look for the first untagged field. If that field
-	// is a slice, then we'll operate on that, and then we'll also look for
-	// params.
+func bindInnerList(b *binder, l InnerList, v reflect.Value) error {
+	v = indirect(v)
+
+	if v.Kind() == reflect.Interface && v.NumMethod() == 0 {
+		v.Set(reflect.ValueOf(dynamicInnerList(l)))
+		return nil
+	}
+
+	// If v is a struct, then look for the field that holds its value. If that
+	// field is a slice, then we'll operate on that, and then we'll also look
+	// for params.
+	//
+	// Otherwise, there's nowhere for params to go.
+	var name string
 	if v.Kind() == reflect.Struct {
-		if err := bindParams(l.Params, v); err != nil {
-			return fmt.Errorf("bind params: %w", err)
+		if err := bindParams(b, l.Params, v); err != nil {
+			return err
 		}
 
-		// Find the first untagged field in v, and try to bind the innerList
-		// items to that field. We'll do that by simply reassigning v to the
-		// relevant field.
-		for j := 0; j < v.NumField(); j++ {
-			if _, ok := v.Type().Field(j).Tag.Lookup("sfv"); !ok {
-				v = v.Field(j)
-				break
-			}
+		// Try to bind the innerList items to the field that holds the value.
+		// We'll do that by simply reassigning v to the relevant field.
+		if f, ok := valueField(v.Type()); ok {
+			name = f.name
+			v = indirect(fieldByIndex(v, f.index))
 		}
+	} else if err := b.checkUnknownParams(l.Params, nil); err != nil {
+		return err
 	}
 
 	if v.Kind() != reflect.Slice {
-		return fmt.Errorf("cannot marshal inner list into %s", v.Type())
+		return wrapBindError(fmt.Errorf("%w: cannot unmarshal inner list into %s", ErrTypeMismatch, v.Type()), "", name)
 	}
 
-	for _, i := range l.Items {
+	for j, i := range l.Items {
 		// This code is similar to that in bindList, but this time the elements
 		// are always going to be items.
 		item := reflect.New(v.Type().Elem())
-		if err := bindItem(i, item.Elem()); err != nil {
-			return err
+		if err := bindItem(b, i, item.Elem(), tag{}); err != nil {
+			return wrapBindError(err, fmt.Sprintf("[%d]", j), name)
 		}
 
 		v.Set(reflect.Append(v, item.Elem()))
@@ -105,9 +222,17 @@ func bindInnerList(l InnerList, v reflect.Value) error {
 	return nil
 }
 
-func bindDictionary(d Dictionary, v reflect.Value) error {
-	if v.Kind() != reflect.Map {
-		return fmt.Errorf("cannot marshal dictionary into %s", v.Type())
+func bindDictionary(b *binder, d Dictionary, v reflect.Value) error {
+	if u, ok := v.Addr().Interface().(DictionaryUnmarshaler); ok {
+		return u.UnmarshalSFVDictionary(d)
+	}
+
+	if v.Kind() == reflect.Struct && isDictionaryStruct(v.Type()) {
+		return bindDictionaryStruct(b, d, v)
+	}
+
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return fmt.Errorf("%w: cannot unmarshal dictionary into %s", ErrTypeMismatch, v.Type())
 	}
 
 	// The zero value of a map is nil, which you can't assign to. So we'll do,
@@ -122,42 +247,168 @@ func bindDictionary(d Dictionary, v reflect.Value) error {
 
 	for k, m := range d.Map {
 		item := reflect.New(v.Type().Elem())
+		if err := bindMember(b, m, item.Elem(), tag{}); err != nil {
+			return wrapBindError(err, fmt.Sprintf("[%q]", k), "")
+		}
 
-		if m.IsItem {
-			if err := bindItem(m.Item, item.Elem()); err != nil {
-				return err
+		v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), item.Elem())
+	}
+
+	return nil
+}
+
+// bindDictionaryStruct binds d to v, a struct marked with the "dictionary"
+// option. Each member is bound to the field with the same name.
+func bindDictionaryStruct(b *binder, d Dictionary, v reflect.Value) error {
+	rest, hasRest := restField(v.Type())
+
+	if b.disallowUnknownKeys && !hasRest {
+		for _, k := range d.Keys {
+			if !hasField(v.Type(), k) {
+				return &BindError{Path: fmt.Sprintf("[%q]", k), Err: ErrUnknownKey}
 			}
-		} else {
-			if err := bindInnerList(m.InnerList, item.Elem()); err != nil {
-				return err
+		}
+	}
+
+	for _, f := range structFields(v.Type()) {
+		if f.tag.name == "" {
+			continue
+		}
+
+		path := fmt.Sprintf("[%q]", f.tag.name)
+
+		m, ok := d.Map[f.tag.name]
+		if !ok {
+			if f.tag.required {
+				return &BindError{Path: path, Field: f.name, Err: ErrMissingParameter}
+			}
+
+			if f.tag.def == "" {
+				continue
+			}
+
+			def, err := f.tag.defaultValue()
+			if err != nil {
+				return wrapBindError(err, path, f.name)
+			}
+
+			if err := bindDefault(b, def, fieldByIndex(v, f.index), f.tag); err != nil {
+				return wrapBindError(err, path, f.name)
+			}
+
+			continue
+		}
+
+		if err := bindMember(b, m, fieldByIndex(v, f.index), f.tag); err != nil {
+			return wrapBindError(err, path, f.name)
+		}
+	}
+
+	if hasRest {
+		rv := fieldByIndex(v, rest.index)
+		if rv.Type() != reflect.TypeOf(Dictionary{}) {
+			err := fmt.Errorf("%w: rest field must be sfv.Dictionary, not %s", ErrTypeMismatch, rv.Type())
+			return wrapBindError(err, "", rest.name)
+		}
+
+		var out Dictionary
+		for _, k := range d.Keys {
+			if !hasField(v.Type(), k) {
+				if out.Map == nil {
+					out.Map = map[string]Member{}
+				}
+
+				out.Keys = append(out.Keys, k)
+				out.Map[k] = d.Map[k]
 			}
 		}
 
-		v.SetMapIndex(reflect.ValueOf(k), item.Elem())
+		rv.Set(reflect.ValueOf(out))
 	}
 
 	return nil
 }
 
-func bindParams(p Params, v reflect.Value) error {
+// bindMember binds m to v. If v is a struct field, t is its tag.
+func bindMember(b *binder, m Member, v reflect.Value, t tag) error {
+	if m.IsItem {
+		return bindItem(b, m.Item, v, t)
+	}
+
+	return bindInnerList(b, m.InnerList, v)
+}
+
+func bindParams(b *binder, p Params, v reflect.Value) error {
 	if v.Kind() != reflect.Struct {
-		return fmt.Errorf("cannot marshal params into %s", v.Type())
+		return fmt.Errorf("%w: cannot unmarshal params into %s", ErrTypeMismatch, v.Type())
+	}
+
+	if err := b.checkUnknownParams(p, v.Type()); err != nil {
+		return err
 	}
 
-	for i := 0; i < v.Type().NumField(); i++ {
-		if paramName, ok := v.Type().Field(i).Tag.Lookup("sfv"); ok {
-			if paramValue, ok := p.Map[paramName]; ok {
-				if err := bindBareItem(paramValue, v.Field(i).Addr().Interface()); err != nil {
-					return fmt.Errorf("%s: %w", paramName, err)
+	for _, f := range structFields(v.Type()) {
+		if f.tag.name == "" {
+			continue
+		}
+
+		paramValue, ok := p.Map[f.tag.name]
+		if !ok {
+			if f.tag.required {
+				return &BindError{Path: paramPath(f.tag.name), Field: f.name, Err: ErrMissingParameter}
+			}
+
+			if f.tag.def == "" {
+				continue
+			}
+
+			def, err := f.tag.defaultValue()
+			if err != nil {
+				return wrapBindError(err, paramPath(f.tag.name), f.name)
+			}
+
+			if err := bindDefault(b, def, fieldByIndex(v, f.index), f.tag); err != nil {
+				return wrapBindError(err, paramPath(f.tag.name), f.name)
+			}
+
+			continue
+		}
+
+		if err := bindBareItem(b, paramValue, indirect(fieldByIndex(v, f.index)).Addr().Interface(), f.tag); err != nil {
+			return wrapBindError(err, paramPath(f.tag.name), f.name)
+		}
+	}
+
+	if rest, ok := restField(v.Type()); ok {
+		rv := fieldByIndex(v, rest.index)
+		if rv.Type() != reflect.TypeOf(Params{}) {
+			err := fmt.Errorf("%w: rest field must be sfv.Params, not %s", ErrTypeMismatch, rv.Type())
+			return wrapBindError(err, "", rest.name)
+		}
+
+		var out Params
+		for _, k := range p.Keys {
+			if !hasField(v.Type(), k) {
+				if out.Map == nil {
+					out.Map = map[string]BareItem{}
 				}
+
+				out.Keys = append(out.Keys, k)
+				out.Map[k] = p.Map[k]
 			}
 		}
+
+		rv.Set(reflect.ValueOf(out))
 	}
 
 	return nil
 }
 
-func bindBareItem(i BareItem, v interface{}) error {
+func bindBareItem(b *binder, i BareItem, v interface{}, t tag) error {
+	if !b.tokenStrings {
+		t.str = true
+	}
+
 	switch v := v.(type) {
 	case *bool:
 		if i.Type == BareItemTypeBoolean {
@@ -166,80 +417,209 @@ func bindBareItem(i BareItem, v interface{}) error {
 		}
 	case *int:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, minInt, maxInt, "int"); err != nil {
+				return err
+			}
+
 			*v = int(i.Integer)
 			return nil
 		}
 	case *int8:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, math.MinInt8, math.MaxInt8, "int8"); err != nil {
+				return err
+			}
+
 			*v = int8(i.Integer)
 			return nil
 		}
 	case *int16:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, math.MinInt16, math.MaxInt16, "int16"); err != nil {
+				return err
+			}
+
 			*v = int16(i.Integer)
 			return nil
 		}
 	case *int32:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, math.MinInt32, math.MaxInt32, "int32"); err != nil {
+				return err
+			}
+
 			*v = int32(i.Integer)
 			return nil
 		}
 	case *int64:
 		if i.Type == BareItemTypeInteger {
-			*v = int64(i.Integer)
+			*v = i.Integer
 			return nil
 		}
 	case *uint:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, 0, maxUint, "uint"); err != nil {
+				return err
+			}
+
 			*v = uint(i.Integer)
 			return nil
 		}
 	case *uint8:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, 0, math.MaxUint8, "uint8"); err != nil {
+				return err
+			}
+
 			*v = uint8(i.Integer)
 			return nil
 		}
 	case *uint16:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, 0, math.MaxUint16, "uint16"); err != nil {
+				return err
+			}
+
 			*v = uint16(i.Integer)
 			return nil
 		}
 	case *uint32:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, 0, math.MaxUint32, "uint32"); err != nil {
+				return err
+			}
+
 			*v = uint32(i.Integer)
 			return nil
 		}
 	case *uint64:
 		if i.Type == BareItemTypeInteger {
+			if err := checkIntegerRange(i.Integer, 0, math.MaxUint64, "uint64"); err != nil {
+				return err
+			}
+
 			*v = uint64(i.Integer)
 			return nil
 		}
 	case *float32:
 		if i.Type == BareItemTypeDecimal {
-			*v = float32(i.Decimal)
+			*v = float32(i.Decimal.Float64())
 			return nil
 		}
 	case *float64:
+		if i.Type == BareItemTypeDecimal {
+			*v = i.Decimal.Float64()
+			return nil
+		}
+	case *Decimal:
 		if i.Type == BareItemTypeDecimal {
 			*v = i.Decimal
 			return nil
 		}
 	case *string:
+		if t.display && i.Type == BareItemTypeDisplayString {
+			*v = i.DisplayString
+			return nil
+		}
+
 		if i.Type == BareItemTypeString {
 			*v = i.String
 			return nil
 		}
 
-		if i.Type == BareItemTypeToken {
+		if i.Type == BareItemTypeToken && !t.str {
 			*v = i.Token
 			return nil
 		}
+	case *Token:
+		if i.Type == BareItemTypeToken {
+			*v = Token(i.Token)
+			return nil
+		}
+	case *DisplayString:
+		if i.Type == BareItemTypeDisplayString {
+			*v = DisplayString(i.DisplayString)
+			return nil
+		}
 	case *[]byte:
 		if i.Type == BareItemTypeBinary {
 			*v = i.Binary
 			return nil
 		}
+	case *time.Time:
+		if i.Type == BareItemTypeDate && !t.unix {
+			*v = time.Unix(i.Date, 0).UTC()
+			return nil
+		}
+
+		if i.Type == BareItemTypeInteger && t.unix {
+			*v = time.Unix(i.Integer, 0).UTC()
+			return nil
+		}
+	case *time.Duration:
+		if i.Type == BareItemTypeInteger && !t.ms {
+			if err := checkIntegerRange(i.Integer, math.MinInt64/int64(time.Second), math.MaxInt64/uint64(time.Second), "time.Duration"); err != nil {
+				return err
+			}
+
+			*v = time.Duration(i.Integer) * time.Second
+			return nil
+		}
+
+		if i.Type == BareItemTypeDecimal && t.ms {
+			ms := i.Decimal.Milli()
+			if ms < math.MinInt64/int64(time.Millisecond) || ms > math.MaxInt64/int64(time.Millisecond) {
+				return fmt.Errorf("%w: %v overflows time.Duration", ErrDecimalRange, i.Decimal)
+			}
+
+			*v = time.Duration(ms) * time.Millisecond
+			return nil
+		}
+	case *interface{}:
+		*v = dynamicBareItem(i)
+		return nil
+	case encoding.TextUnmarshaler:
+		if t.display && i.Type == BareItemTypeDisplayString {
+			return v.UnmarshalText([]byte(i.DisplayString))
+		}
+
+		if i.Type == BareItemTypeString {
+			return v.UnmarshalText([]byte(i.String))
+		}
+
+		if i.Type == BareItemTypeToken && !t.str {
+			return v.UnmarshalText([]byte(i.Token))
+		}
 	}
 
-	return fmt.Errorf("cannot marshal to %T from %s", v, i.Type)
+	return fmt.Errorf("%w: cannot unmarshal %s into %s", ErrTypeMismatch, i.Type, reflect.TypeOf(v).Elem())
+}
+
+// bindDefault binds def, the default value of a field tagged with t, to v. It
+// returns an error if v isn't serialized as the same type of bare item as def,
+// since the field would otherwise be parsed from its default, but then fail to
+// serialize, or serialize as something else.
+func bindDefault(b *binder, def BareItem, v reflect.Value, t tag) error {
+	if err := bindMember(b, Member{IsItem: true, Item: Item{BareItem: def}}, v, t); err != nil {
+		return err
+	}
+
+	u := unbinder{tokenStrings: b.tokenStrings}
+	m, err := unbindMember(&u, v, t)
+	if err != nil || !m.IsItem || m.Item.BareItem.Type != def.Type {
+		return fmt.Errorf("%w: default %s is a %s, but the field isn't serialized as one", ErrTypeMismatch, t.def, def.Type)
+	}
+
+	return nil
+}
+
+// checkIntegerRange returns an error if n is outside of the range [min, max] of
+// the Go type typ. SFV integers can be larger than some Go integer types can
+// hold, and binding them without this check would silently truncate them.
+func checkIntegerRange(n, min int64, max uint64, typ string) error {
+	if n < min || (n > 0 && uint64(n) > max) {
+		return fmt.Errorf("%w: %d overflows %s", ErrIntegerRange, n, typ)
+	}
+
+	return nil
 }
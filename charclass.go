@@ -32,10 +32,28 @@ func isTChar(b byte) bool {
 		b == '~'
 }
 
+func isLCHexDigit(b byte) bool {
+	return isDigit(b) || (b >= 0x61 && b <= 0x66)
+}
+
+const lcHexDigits = "0123456789abcdef"
+
+func unhex(b byte) byte {
+	if isDigit(b) {
+		return b - 0x30
+	}
+
+	return b - 0x61 + 10
+}
+
 func isLCAlpha(b byte) bool {
 	return b >= 0x61 && b <= 0x7A
 }
 
+func isKeyChar(b byte) bool {
+	return isLCAlpha(b) || isDigit(b) || b == '_' || b == '-' || b == '.' || b == '*'
+}
+
 func isOWS(b byte) bool {
 	return b == ' ' || b == '\t'
 }
@@ -0,0 +1,147 @@
+package sfv
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// Decimal is an exact SFV decimal. Decimals have at most 12 digits before the
+// decimal point and at most 3 digits after it, and are stored as an integer
+// number of thousandths.
+//
+// The zero value is the decimal 0.0.
+type Decimal struct {
+	milli int64
+}
+
+// maxDecimalMilli is the largest magnitude a Decimal can have while still
+// being serializable.
+const maxDecimalMilli = 999_999_999_999_999
+
+// DecimalFromMilli returns the Decimal with the value n/1000.
+func DecimalFromMilli(n int64) Decimal {
+	return Decimal{milli: n}
+}
+
+// DecimalFromFloat64 returns the Decimal closest to f, rounding to three
+// decimal places with ties going to even. It returns an error wrapping
+// ErrDecimalRange if f is not finite or is too large to be a Decimal.
+func DecimalFromFloat64(f float64) (Decimal, error) {
+	if math.IsNaN(f) || math.IsInf(f, 0) || math.Abs(f) >= 1e12 {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrDecimalRange, f)
+	}
+
+	// Rounding the integer and fractional parts separately keeps the integer
+	// part exact, even when f has all 12 integer digits.
+	i, frac := math.Modf(f)
+	d := Decimal{milli: int64(i)*1000 + int64(math.RoundToEven(frac*1000))}
+	if !d.valid() {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrDecimalRange, f)
+	}
+
+	return d, nil
+}
+
+// DecimalFromRat returns the Decimal closest to r, rounding to three decimal
+// places with ties going to even. It returns an error wrapping ErrDecimalRange
+// if r is too large to be a Decimal.
+func DecimalFromRat(r *big.Rat) (Decimal, error) {
+	n := new(big.Int).Mul(r.Num(), big.NewInt(1000))
+	q, m := n.QuoRem(n, r.Denom(), new(big.Int))
+
+	// QuoRem truncates towards zero, so round q away from zero if the
+	// remainder is more than half of the denominator, or exactly half and q is
+	// odd.
+	c := m.Lsh(m.Abs(m), 1).Cmp(r.Denom())
+	if c > 0 || (c == 0 && q.Bit(0) == 1) {
+		q.Add(q, big.NewInt(int64(r.Sign())))
+	}
+
+	if !q.IsInt64() {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrDecimalRange, r.FloatString(3))
+	}
+
+	d := Decimal{milli: q.Int64()}
+	if !d.valid() {
+		return Decimal{}, fmt.Errorf("%w: %v", ErrDecimalRange, r.FloatString(3))
+	}
+
+	return d, nil
+}
+
+// ParseDecimal parses s as an SFV decimal, such as "1.5" or "-0.25". Integers,
+// such as "3", are also accepted.
+func ParseDecimal(s string) (Decimal, error) {
+	scan := scanner{s: s}
+
+	n, err := parseNumber(&scan)
+	if err != nil {
+		return Decimal{}, err
+	}
+
+	if !scan.isEOF() {
+		return Decimal{}, scan.parseError(ErrTrailingCharacters, "end of input", "illegal trailing characters")
+	}
+
+	if n.Type == BareItemTypeInteger {
+		d := Decimal{milli: n.Integer * 1000}
+		if !d.valid() {
+			return Decimal{}, scan.parseErrorAt(0, ErrDecimalRange, "at most 12 integer digits", "too many digits in number")
+		}
+
+		return d, nil
+	}
+
+	return n.Decimal, nil
+}
+
+// Milli returns d as an integer number of thousandths.
+func (d Decimal) Milli() int64 {
+	return d.milli
+}
+
+// Float64 returns the float64 closest to d.
+func (d Decimal) Float64() float64 {
+	return float64(d.milli) / 1000
+}
+
+// Rat returns d as a big.Rat.
+func (d Decimal) Rat() *big.Rat {
+	return big.NewRat(d.milli, 1000)
+}
+
+// String returns the SFV serialization of d, such as "1.5" or "-0.25".
+func (d Decimal) String() string {
+	return string(d.append(nil))
+}
+
+func (d Decimal) valid() bool {
+	return d.milli >= -maxDecimalMilli && d.milli <= maxDecimalMilli
+}
+
+// append appends the SFV serialization of d to b.
+func (d Decimal) append(b []byte) []byte {
+	n := uint64(d.milli)
+	if d.milli < 0 {
+		b = append(b, '-')
+		n = uint64(-d.milli)
+	}
+
+	b = strconv.AppendUint(b, n/1000, 10)
+	b = append(b, '.')
+
+	// Write the fractional part with as few digits as possible, but always at
+	// least one.
+	frac := n % 1000
+	b = append(b, byte('0'+frac/100))
+	if frac%100 != 0 {
+		b = append(b, byte('0'+frac/10%10))
+	}
+	if frac%10 != 0 {
+		b = append(b, byte('0'+frac%10))
+	}
+
+	return b
+}
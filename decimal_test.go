@@ -0,0 +1,207 @@
+package sfv_test
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/ucarion/sfv"
+)
+
+func ExampleDecimal() {
+	type invoice struct {
+		Total sfv.Decimal `sfv:"total"`
+		Tax   float64     `sfv:"tax"`
+	}
+
+	var data invoice
+	fmt.Println(sfv.Unmarshal("x;total=999999999999.999;tax=0.125", &data))
+	fmt.Println(data.Total, data.Total.Milli(), data.Tax)
+
+	// Output:
+	// <nil>
+	// 999999999999.999 999999999999999 0.125
+}
+
+func ExampleParseDecimal() {
+	d, err := sfv.ParseDecimal("-12.5")
+	fmt.Println(err)
+	fmt.Println(d, d.Rat(), d.Float64())
+
+	// Output:
+	// <nil>
+	// -12.5 -25/2 -12.5
+}
+
+func TestDecimal_String(t *testing.T) {
+	testCases := []struct {
+		In  int64
+		Out string
+	}{
+		{In: 0, Out: "0.0"},
+		{In: 1000, Out: "1.0"},
+		{In: 1500, Out: "1.5"},
+		{In: 1050, Out: "1.05"},
+		{In: 1005, Out: "1.005"},
+		{In: 1501, Out: "1.501"},
+		{In: -1, Out: "-0.001"},
+		{In: -999_999_999_999_999, Out: "-999999999999.999"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			if out := sfv.DecimalFromMilli(tt.In).String(); out != tt.Out {
+				t.Errorf("bad string: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestDecimalFromFloat64(t *testing.T) {
+	testCases := []struct {
+		In  float64
+		Out int64
+		Err bool
+	}{
+		{In: 0, Out: 0},
+		{In: 0.5, Out: 500},
+		{In: -0.5, Out: -500},
+		{In: 0.0005, Out: 0},
+		{In: 0.0015, Out: 2},
+		{In: 999_999_999_999.999, Out: 999_999_999_999_999},
+		{In: -999_999_999_999.999, Out: -999_999_999_999_999},
+		{In: 1e12, Err: true},
+		{In: math.NaN(), Err: true},
+		{In: math.Inf(1), Err: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprint(tt.In), func(t *testing.T) {
+			d, err := sfv.DecimalFromFloat64(tt.In)
+			if tt.Err {
+				if !errors.Is(err, sfv.ErrDecimalRange) {
+					t.Errorf("bad err: want: %v, got: %v", sfv.ErrDecimalRange, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if d.Milli() != tt.Out {
+				t.Errorf("bad milli: want: %d, got: %d", tt.Out, d.Milli())
+			}
+		})
+	}
+}
+
+func TestDecimalFromRat(t *testing.T) {
+	testCases := []struct {
+		In  string
+		Out int64
+		Err bool
+	}{
+		{In: "0", Out: 0},
+		{In: "1/3", Out: 333},
+		{In: "2/3", Out: 667},
+		{In: "-2/3", Out: -667},
+		{In: "0.0005", Out: 0},
+		{In: "0.0015", Out: 2},
+		{In: "-0.0015", Out: -2},
+		{In: "0.00051", Out: 1},
+		{In: "999999999999.999", Out: 999_999_999_999_999},
+		{In: "999999999999.9995", Err: true},
+		{In: "1e30", Err: true},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			r, ok := new(big.Rat).SetString(tt.In)
+			if !ok {
+				t.Fatalf("bad rat: %s", tt.In)
+			}
+
+			d, err := sfv.DecimalFromRat(r)
+			if tt.Err {
+				if !errors.Is(err, sfv.ErrDecimalRange) {
+					t.Errorf("bad err: want: %v, got: %v", sfv.ErrDecimalRange, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if d.Milli() != tt.Out {
+				t.Errorf("bad milli: want: %d, got: %d", tt.Out, d.Milli())
+			}
+		})
+	}
+}
+
+func TestParseDecimal(t *testing.T) {
+	testCases := []struct {
+		In  string
+		Out int64
+		Err error
+	}{
+		{In: "0.0", Out: 0},
+		{In: "1.5", Out: 1500},
+		{In: "-0.001", Out: -1},
+		{In: "42", Out: 42000},
+		{In: "999999999999.999", Out: 999_999_999_999_999},
+		{In: "1.2345", Err: sfv.ErrDecimalRange},
+		{In: "1000000000000", Err: sfv.ErrDecimalRange},
+		{In: "1.5 ", Err: sfv.ErrTrailingCharacters},
+		{In: "abc", Err: sfv.ErrSyntax},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			d, err := sfv.ParseDecimal(tt.In)
+			if tt.Err != nil {
+				if !errors.Is(err, tt.Err) {
+					t.Errorf("bad err: want: %v, got: %v", tt.Err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if d.Milli() != tt.Out {
+				t.Errorf("bad milli: want: %d, got: %d", tt.Out, d.Milli())
+			}
+		})
+	}
+}
+
+func TestDecimal_round_trip(t *testing.T) {
+	// These values can't be represented exactly as a float64, but must survive
+	// a round-trip through a Decimal exactly.
+	for _, in := range []string{"999999999999.999", "-123456789012.345", "0.001"} {
+		t.Run(in, func(t *testing.T) {
+			var item sfv.Item
+			if err := sfv.Unmarshal(in, &item); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			out, err := sfv.Marshal(item)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != in {
+				t.Errorf("bad round-trip: want: %q, got: %q", in, out)
+			}
+		})
+	}
+}
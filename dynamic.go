@@ -0,0 +1,92 @@
+package sfv
+
+import (
+	"reflect"
+	"time"
+)
+
+// Parameterized is an item or inner list that has parameters. Unmarshal uses
+// it when binding an item or inner list with parameters to an empty interface,
+// and Marshal accepts it in the same places.
+//
+// Value holds the bare item or inner list, and Params holds the parameters.
+// Both use the same Go types as other values bound to an empty interface.
+// Keys holds the names of the parameters, in order. If Keys is nil, Marshal
+// serializes Params with the keys sorted, in the same order as the members of
+// a map.
+type Parameterized struct {
+	Value  interface{}
+	Params map[string]interface{}
+	Keys   []string
+}
+
+// dynamicBareItem returns the value Unmarshal stores for i in an empty
+// interface.
+func dynamicBareItem(i BareItem) interface{} {
+	switch i.Type {
+	case BareItemTypeInteger:
+		return i.Integer
+	case BareItemTypeDecimal:
+		return i.Decimal.Float64()
+	case BareItemTypeString:
+		return i.String
+	case BareItemTypeToken:
+		return Token(i.Token)
+	case BareItemTypeBinary:
+		return i.Binary
+	case BareItemTypeBoolean:
+		return i.Boolean
+	case BareItemTypeDate:
+		return time.Unix(i.Date, 0).UTC()
+	case BareItemTypeDisplayString:
+		return DisplayString(i.DisplayString)
+	}
+
+	return nil
+}
+
+// dynamicItem returns the value Unmarshal stores for i in an empty interface.
+// Items without parameters are stored as their bare item alone.
+func dynamicItem(i Item) interface{} {
+	return withParams(dynamicBareItem(i.BareItem), i.Params)
+}
+
+// dynamicInnerList returns the value Unmarshal stores for l in an empty
+// interface, a []interface{} holding each of its items.
+func dynamicInnerList(l InnerList) interface{} {
+	items := make([]interface{}, len(l.Items))
+	for i, item := range l.Items {
+		items[i] = dynamicItem(item)
+	}
+
+	return withParams(items, l.Params)
+}
+
+// withParams returns v wrapped in a Parameterized, if p is not empty.
+// Otherwise, it returns v.
+func withParams(v interface{}, p Params) interface{} {
+	if len(p.Keys) == 0 {
+		return v
+	}
+
+	params := make(map[string]interface{}, len(p.Keys))
+	for _, k := range p.Keys {
+		params[k] = dynamicBareItem(p.Map[k])
+	}
+
+	keys := make([]string, len(p.Keys))
+	copy(keys, p.Keys)
+
+	return Parameterized{Value: v, Params: params, Keys: keys}
+}
+
+// dynamicTag returns t, adjusted for v being an interface. Unmarshal stores
+// strings in empty interfaces as a Go string, and tokens as a Token, so strings
+// held in an interface are serialized as strings rather than tokens.
+func dynamicTag(v reflect.Value, t tag) tag {
+	if v.Kind() == reflect.Interface {
+		t.str = true
+	}
+
+	return t
+}
@@ -0,0 +1,173 @@
+package sfv_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ucarion/sfv"
+)
+
+func ExampleParameterized() {
+	var data map[string]interface{}
+	fmt.Println(sfv.Unmarshal(`a=1, b=text/html;q=0.5, c=("x" y)`, &data))
+	fmt.Printf("%#v\n", data["a"])
+	fmt.Printf("%#v\n", data["b"])
+	fmt.Printf("%#v\n", data["c"])
+
+	out, err := sfv.Marshal(data)
+	fmt.Println(out, err)
+
+	// Output:
+	// <nil>
+	// 1
+	// sfv.Parameterized{Value:"text/html", Params:map[string]interface {}{"q":0.5}, Keys:[]string{"q"}}
+	// []interface {}{"x", "y"}
+	// a=1, b=text/html;q=0.5, c=("x" y) <nil>
+}
+
+func TestUnmarshal_dynamic(t *testing.T) {
+	// Each of these is unmarshaled into an *interface{}, and so is parsed as an
+	// item.
+	testCases := []struct {
+		In  string
+		Out interface{}
+	}{
+		{In: "1", Out: int64(1)},
+		{In: "1.5", Out: 1.5},
+		{In: `"a"`, Out: "a"},
+		{In: "a", Out: sfv.Token("a")},
+		{In: ":AQID:", Out: []byte{1, 2, 3}},
+		{In: "?1", Out: true},
+		{In: "@1659578233", Out: time.Unix(1659578233, 0).UTC()},
+		{In: `%"f%c3%bc"`, Out: sfv.DisplayString("fü")},
+		{In: `a;b="c";d`, Out: sfv.Parameterized{Value: sfv.Token("a"), Params: map[string]interface{}{"b": "c", "d": true}, Keys: []string{"b", "d"}}},
+		{In: "a;z=1;b=2", Out: sfv.Parameterized{Value: sfv.Token("a"), Params: map[string]interface{}{"z": int64(1), "b": int64(2)}, Keys: []string{"z", "b"}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			var out interface{}
+			if err := sfv.Unmarshal(tt.In, &out); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out, tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_dynamic_containers(t *testing.T) {
+	testCases := []struct {
+		In  string
+		Out interface{}
+	}{
+		{In: "1, (a b);c=1, ()", Out: []interface{}{
+			int64(1),
+			sfv.Parameterized{Value: []interface{}{sfv.Token("a"), sfv.Token("b")}, Params: map[string]interface{}{"c": int64(1)}, Keys: []string{"c"}},
+			[]interface{}{},
+		}},
+		{In: "a, b=(1;x 2)", Out: map[string]interface{}{
+			"a": true,
+			"b": []interface{}{sfv.Parameterized{Value: int64(1), Params: map[string]interface{}{"x": true}, Keys: []string{"x"}}, int64(2)},
+		}},
+		{In: "a;b=1;c", Out: struct {
+			Value interface{}
+			B     interface{} `sfv:"b"`
+			C     interface{} `sfv:"c"`
+			D     interface{} `sfv:"d"`
+		}{Value: sfv.Token("a"), B: int64(1), C: true}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			out := reflect.New(reflect.TypeOf(tt.Out))
+			if err := sfv.Unmarshal(tt.In, out.Interface()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out.Elem().Interface(), tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out.Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestMarshal_dynamic(t *testing.T) {
+	testCases := []struct {
+		In  interface{}
+		Out string
+	}{
+		{In: sfv.Token("a"), Out: "a"},
+		{In: sfv.DisplayString("fü"), Out: `%"f%c3%bc"`},
+		{In: sfv.Parameterized{Value: "a", Params: map[string]interface{}{"c": sfv.Token("d"), "b": "c"}}, Out: `"a";b="c";c=d`},
+		{In: sfv.Parameterized{Value: "a", Params: map[string]interface{}{"c": sfv.Token("d"), "b": "c"}, Keys: []string{"c", "b"}}, Out: `"a";c=d;b="c"`},
+		{In: []interface{}{"a", sfv.Token("b"), int64(1), 1.5, []byte{1}, false, time.Unix(1, 0)}, Out: `"a", b, 1, 1.5, :AQ==:, ?0, @1`},
+		{In: []interface{}{[]interface{}{"a", sfv.Token("b")}, []interface{}{}}, Out: `("a" b), ()`},
+		{In: []interface{}{sfv.Parameterized{Value: []interface{}{int64(1)}, Params: map[string]interface{}{"a": int64(2)}}}, Out: "(1);a=2"},
+		{In: map[string]interface{}{"b": []interface{}{int64(1)}, "a": true}, Out: "a, b=(1)"},
+		{In: struct {
+			Value interface{}
+			B     interface{} `sfv:"b"`
+			C     interface{} `sfv:"c"`
+		}{Value: "a", B: "b"}, Out: `"a";b="b"`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(tt.In)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestDynamic_round_trip(t *testing.T) {
+	for _, in := range []string{
+		`a=1, b=2.5, c="x", d=y, e=:AQID:, f=?0, g=@1, h=%"f%c3%bc"`,
+		`a=(1 "2" x);b;c=?0, d;e=1`,
+		`a;z=1;b=2`,
+	} {
+		t.Run(in, func(t *testing.T) {
+			var data map[string]interface{}
+			if err := sfv.Unmarshal(in, &data); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			out, err := sfv.Marshal(data)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != in {
+				t.Errorf("bad round-trip: want: %q, got: %q", in, out)
+			}
+		})
+	}
+}
+
+func TestMarshal_dynamic_out_of_sync_keys(t *testing.T) {
+	testCases := []sfv.Parameterized{
+		{Value: true, Params: map[string]interface{}{"a": true, "b": true}, Keys: []string{"a"}},
+		{Value: true, Params: map[string]interface{}{"a": true}, Keys: []string{"b"}},
+		{Value: true, Params: map[string]interface{}{"a": true, "b": true}, Keys: []string{"a", "a"}},
+		{Value: true, Params: map[string]interface{}{}, Keys: []string{"a"}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprint(tt.Keys), func(t *testing.T) {
+			if _, err := sfv.Marshal(tt); !errors.Is(err, sfv.ErrInvalidKey) {
+				t.Errorf("want ErrInvalidKey, got: %v", err)
+			}
+		})
+	}
+}
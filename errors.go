@@ -0,0 +1,177 @@
+package sfv
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrUnexpectedEOF indicates that the input ended before a complete
+	// structured field was parsed.
+	ErrUnexpectedEOF = errors.New("sfv: unexpected end of input")
+
+	// ErrSyntax indicates that the input is not valid structured field syntax.
+	// It is used when none of the more specific errors below apply.
+	ErrSyntax = errors.New("sfv: invalid syntax")
+
+	// ErrTrailingCharacters indicates that a complete structured field was
+	// parsed, but was followed by additional input.
+	ErrTrailingCharacters = errors.New("sfv: trailing characters")
+
+	// ErrIntegerRange indicates that an integer or date has too many digits,
+	// or is outside the range the specification permits.
+	ErrIntegerRange = errors.New("sfv: integer out of range")
+
+	// ErrDecimalRange indicates that a decimal has too many digits, or is
+	// outside the range the specification permits.
+	ErrDecimalRange = errors.New("sfv: decimal out of range")
+
+	// ErrInvalidKey indicates that a dictionary or parameter key is invalid.
+	ErrInvalidKey = errors.New("sfv: invalid key")
+
+	// ErrInvalidString indicates that a string or display string contains
+	// invalid characters.
+	ErrInvalidString = errors.New("sfv: invalid string")
+
+	// ErrInvalidToken indicates that a token contains invalid characters.
+	ErrInvalidToken = errors.New("sfv: invalid token")
+
+	// ErrInvalidBinary indicates that a byte sequence is not valid base64.
+	ErrInvalidBinary = errors.New("sfv: invalid byte sequence")
+
+	// ErrLimitExceeded indicates that the input exceeds one of the Limits set
+	// on a Decoder.
+	ErrLimitExceeded = errors.New("sfv: limit exceeded")
+
+	// ErrMissingParameter indicates that a parameter or dictionary member
+	// marked as required in a struct tag is not present in the parsed data.
+	ErrMissingParameter = errors.New("sfv: missing required parameter")
+
+	// ErrUnknownKey indicates that a Decoder with DisallowUnknownKeys set
+	// found a parameter or dictionary member with no corresponding field.
+	ErrUnknownKey = errors.New("sfv: unknown key")
+
+	// ErrUnsupportedType indicates that a Go type cannot be converted to or
+	// from structured field data, or that a type of bare item is not supported
+	// by the Spec in use.
+	ErrUnsupportedType = errors.New("sfv: unsupported type")
+
+	// ErrTypeMismatch indicates that parsed structured field data has a
+	// different type than the Go value it is being stored in.
+	ErrTypeMismatch = errors.New("sfv: type mismatch")
+)
+
+// ParseError is the type of error returned when parsing fails.
+type ParseError struct {
+	// Line is the index of the field line the error occurred in. It is always
+	// zero unless the input was parsed with UnmarshalLines.
+	Line int
+
+	// Offset is the byte offset within the line the error occurred at.
+	Offset int
+
+	// Err is the kind of error that occurred, such as ErrSyntax or
+	// ErrUnexpectedEOF.
+	Err error
+
+	// Expected is a description of what the parser expected to find at
+	// Offset, such as "','" or "bare item". It is empty if there is no more
+	// specific description than Err.
+	Expected string
+
+	// Input is the line that was being parsed.
+	Input string
+
+	msg string
+}
+
+func (pe *ParseError) Error() string {
+	return pe.msg
+}
+
+func (pe *ParseError) Unwrap() error {
+	return pe.Err
+}
+
+// snippetContext is the number of bytes of input Format will show on either
+// side of the offset of a ParseError.
+const snippetContext = 32
+
+// Format implements fmt.Formatter. The %+v verb renders the error along with
+// its position and a snippet of the input, with a caret under the offending
+// byte:
+//
+//	invalid start of bare item (expected bare item) at line 1, column 4:
+//	  a, ]b
+//	     ^
+//
+// All other verbs render the same message as Error.
+func (pe *ParseError) Format(f fmt.State, verb rune) {
+	switch {
+	case verb == 'v' && f.Flag('+'):
+		io.WriteString(f, pe.msg)
+		if pe.Expected != "" {
+			fmt.Fprintf(f, " (expected %s)", pe.Expected)
+		}
+
+		fmt.Fprintf(f, " at line %d, column %d:\n", pe.Line+1, pe.Offset+1)
+
+		start, end, prefix, suffix := 0, len(pe.Input), "", ""
+		if pe.Offset-start > snippetContext {
+			start, prefix = pe.Offset-snippetContext, "..."
+		}
+
+		if end-pe.Offset > snippetContext {
+			end, suffix = pe.Offset+snippetContext, "..."
+		}
+
+		// Replace anything that isn't printable ASCII, so that each byte of
+		// input takes up exactly one column, and the caret lines up.
+		snippet := []byte(pe.Input[start:end])
+		for i, b := range snippet {
+			if b != ' ' && !isVisible(b) {
+				snippet[i] = '.'
+			}
+		}
+
+		fmt.Fprintf(f, "  %s%s%s\n", prefix, snippet, suffix)
+		fmt.Fprintf(f, "  %s^", strings.Repeat(" ", len(prefix)+pe.Offset-start))
+	case verb == 'q':
+		fmt.Fprintf(f, "%q", pe.msg)
+	default:
+		io.WriteString(f, pe.msg)
+	}
+}
+
+// BindError is the type of error returned when parsed data can't be stored in
+// the Go value passed to Unmarshal, or when part of the Go value passed to
+// Marshal can't be converted into structured field data.
+type BindError struct {
+	// Path locates the data that couldn't be converted. It starts with "item",
+	// "list", or "dict", followed by list indices, dictionary keys, and
+	// parameter names, such as `dict["u"].params["q"]` or `list[2]`.
+	Path string
+
+	// Field is the name of the Go struct field the data was being converted
+	// to or from, such as "Weight". Nested fields are separated by dots. It is empty if
+	// the data was not being stored in a struct field.
+	Field string
+
+	// Err is the underlying error, such as ErrTypeMismatch, or the error
+	// returned by an ItemUnmarshaler.
+	Err error
+}
+
+func (be *BindError) Error() string {
+	if be.Field == "" {
+		return be.Path + ": " + be.Err.Error()
+	}
+
+	return be.Path + " (field " + be.Field + "): " + be.Err.Error()
+}
+
+func (be *BindError) Unwrap() error {
+	return be.Err
+}
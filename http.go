@@ -0,0 +1,113 @@
+package sfv
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/textproto"
+	"reflect"
+)
+
+// ErrHeaderNotPresent is returned by GetHeader when the requested header does
+// not appear in the http.Header at all.
+//
+// The specification requires that a header that is present but fails to parse
+// be ignored entirely. GetHeader returns a different error in that case, so
+// that callers can tell the two situations apart.
+var ErrHeaderNotPresent = errors.New("sfv: header not present")
+
+// GetHeader parses the header called name in h, and stores the result in v. It
+// is equivalent to calling GetHeader on a zero Decoder.
+func GetHeader(h http.Header, name string, v interface{}) error {
+	var d Decoder
+	return d.GetHeader(h, name, v)
+}
+
+// SetHeader serializes v and sets it as the value of the header called name in
+// h, replacing any existing values. It is equivalent to calling SetHeader on a
+// zero Encoder.
+func SetHeader(h http.Header, name string, v interface{}) error {
+	var e Encoder
+	return e.SetHeader(h, name, v)
+}
+
+// AddHeader serializes v and adds it as a new line of the header called name in
+// h. It is equivalent to calling AddHeader on a zero Encoder.
+func AddHeader(h http.Header, name string, v interface{}) error {
+	var e Encoder
+	return e.AddHeader(h, name, v)
+}
+
+// GetHeader parses the header called name in h, and stores the result in v,
+// according to the options in d. All of the lines of the header are combined,
+// as in UnmarshalLines.
+//
+// If the header is not present in h, GetHeader returns ErrHeaderNotPresent. If
+// the header is present but invalid, GetHeader returns the parsing error and v
+// is left unmodified.
+func (d *Decoder) GetHeader(h http.Header, name string, v interface{}) error {
+	lines := h[textproto.CanonicalMIMEHeaderKey(name)]
+	if len(lines) == 0 {
+		return ErrHeaderNotPresent
+	}
+
+	val := reflect.ValueOf(v)
+	if val.Kind() != reflect.Ptr || val.IsNil() {
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+
+	// Unmarshal into a fresh value, so that v is untouched if the header turns
+	// out to be invalid.
+	out := reflect.New(val.Type().Elem())
+	if err := d.UnmarshalLines(lines, out.Interface()); err != nil {
+		return err
+	}
+
+	val.Elem().Set(out.Elem())
+	return nil
+}
+
+// SetHeader serializes v and sets it as the value of the header called name in
+// h, according to the options in e. Any existing values of the header are
+// replaced.
+func (e *Encoder) SetHeader(h http.Header, name string, v interface{}) error {
+	s, err := e.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	h.Set(name, s)
+	return nil
+}
+
+// AddHeader serializes v and adds it as a new line of the header called name in
+// h, according to the options in e.
+//
+// Combining multiple lines of a header is only meaningful for lists and
+// dictionaries, so AddHeader returns an error if v serializes to an item.
+func (e *Encoder) AddHeader(h http.Header, name string, v interface{}) error {
+	u := unbinder{keyLess: e.KeyLess, tokenStrings: e.TokenStrings}
+
+	field, err := unbind(&u, v)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := field.(Item); ok {
+		return fmt.Errorf("%w: cannot add item to header: %T", ErrUnsupportedType, v)
+	}
+
+	w := serializer{spec: e.Spec}
+	if err := marshalField(&w, field); err != nil {
+		return err
+	}
+
+	// An empty list or dictionary adds nothing to a header. Adding an empty
+	// line anyway would be harmless, but it's noise on the wire.
+	if len(w.buf) == 0 {
+		return nil
+	}
+
+	h.Add(name, string(w.buf))
+	return nil
+}
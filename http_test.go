@@ -0,0 +1,109 @@
+package sfv_test
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/ucarion/sfv"
+)
+
+func ExampleGetHeader() {
+	header := http.Header{}
+	header.Add("Accept-Encoding", "gzip, br;q=0.9")
+	header.Add("Accept-Encoding", "*;q=0.1")
+
+	var encodings []sfv.Token
+	fmt.Println(sfv.GetHeader(header, "Accept-Encoding", &encodings))
+	fmt.Println(encodings)
+
+	var params map[string]int
+	fmt.Println(sfv.GetHeader(header, "Sec-Params", &params))
+
+	// Output:
+	// <nil>
+	// [gzip br *]
+	// sfv: header not present
+}
+
+func ExampleSetHeader() {
+	header := http.Header{}
+	fmt.Println(sfv.SetHeader(header, "Accept-Encoding", []sfv.Token{"gzip", "br"}))
+	fmt.Println(header)
+
+	// Output:
+	// <nil>
+	// map[Accept-Encoding:[gzip, br]]
+}
+
+func ExampleAddHeader() {
+	header := http.Header{}
+	fmt.Println(sfv.AddHeader(header, "Accept-Encoding", []sfv.Token{"gzip", "br"}))
+	fmt.Println(sfv.AddHeader(header, "Accept-Encoding", []sfv.Token{"identity"}))
+	fmt.Println(header)
+
+	// Output:
+	// <nil>
+	// <nil>
+	// map[Accept-Encoding:[gzip, br identity]]
+}
+
+func TestGetHeader(t *testing.T) {
+	t.Run("not present", func(t *testing.T) {
+		out := []sfv.Token{"x"}
+		err := sfv.GetHeader(http.Header{}, "Foo", &out)
+
+		if !errors.Is(err, sfv.ErrHeaderNotPresent) {
+			t.Errorf("bad err: want: %v, got: %v", sfv.ErrHeaderNotPresent, err)
+		}
+
+		if !reflect.DeepEqual([]sfv.Token{"x"}, out) {
+			t.Errorf("out modified: %#v", out)
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		header := http.Header{}
+		header.Add("Foo", "a, b")
+		header.Add("Foo", "c;")
+
+		out := []sfv.Token{"x"}
+		err := sfv.GetHeader(header, "foo", &out)
+
+		if err == nil || errors.Is(err, sfv.ErrHeaderNotPresent) {
+			t.Errorf("bad err: want parse error, got: %v", err)
+		}
+
+		if !reflect.DeepEqual([]sfv.Token{"x"}, out) {
+			t.Errorf("out modified: %#v", out)
+		}
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		header := http.Header{}
+		header.Add("Foo", "a, b")
+		header.Add("Foo", "c")
+
+		out := []sfv.Token{"x"}
+		if err := sfv.GetHeader(header, "foo", &out); err != nil {
+			t.Errorf("err: %v", err)
+		}
+
+		if !reflect.DeepEqual([]sfv.Token{"a", "b", "c"}, out) {
+			t.Errorf("bad result: %#v", out)
+		}
+	})
+}
+
+func TestAddHeader_item(t *testing.T) {
+	header := http.Header{}
+	if err := sfv.AddHeader(header, "Foo", "bar"); err == nil {
+		t.Errorf("must fail, but err is nil")
+	}
+
+	if len(header) != 0 {
+		t.Errorf("header modified: %#v", header)
+	}
+}
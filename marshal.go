@@ -1,80 +1,125 @@
 package sfv
 
 import (
-	"encoding/base64"
+	"errors"
 	"fmt"
-	"math"
-	"reflect"
+	"io"
 	"strconv"
-	"strings"
+	"unicode/utf8"
 )
 
+// Marshal returns the SFV serialization of v. It is equivalent to calling
+// Marshal on a zero Encoder.
 func Marshal(v interface{}) (string, error) {
-	var w strings.Builder
+	var e Encoder
+	return e.Marshal(v)
+}
 
-	switch v := v.(type) {
-	case Item:
-		if err := marshalItem(&w, v); err != nil {
-			return "", err
-		}
-	case List:
-		if err := marshalList(&w, v); err != nil {
-			return "", err
-		}
-	case Dictionary:
-		if err := marshalDictionary(&w, v); err != nil {
-			return "", err
-		}
-	case bool, int, int8, int16, int32, int64, uint, uint8, uint16,
-		uint32, uint64, float32, float64, string, []byte:
-		item, err := unbindItem(reflect.ValueOf(v))
-		if err != nil {
-			return "", err
-		}
+// AppendMarshal appends the SFV serialization of v to dst, and returns the
+// extended buffer. It is equivalent to calling AppendMarshal on a zero Encoder.
+func AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	var e Encoder
+	return e.AppendMarshal(dst, v)
+}
 
-		if err := marshalItem(&w, item); err != nil {
-			return "", err
-		}
-	default:
-		val := reflect.ValueOf(v)
+// Encoder holds options for serializing SFV data. The zero value is ready to
+// use, and serializes according to RFC9651.
+//
+// An Encoder created with NewEncoder can also write serialized data to a
+// stream, with Encode.
+type Encoder struct {
+	// Spec is the revision of the specification to serialize according to.
+	Spec Spec
+
+	// KeyLess, if non-nil, reports whether the key a of a Go map must be
+	// serialized before the key b. If KeyLess is nil, keys are serialized in
+	// increasing order.
+	//
+	// To serialize members in an arbitrary order, use a Dictionary, or a type
+	// that implements DictionaryMarshaler, instead of a map.
+	KeyLess func(a, b string) bool
+
+	// TokenStrings causes Go strings to be serialized as tokens, as they were
+	// before Token was added. Fields with the "string" option in their struct
+	// tag are still serialized as strings. This applies to types that
+	// implement encoding.TextMarshaler, too. By default, Go strings are
+	// serialized as strings. Use Token to serialize tokens.
+	TokenStrings bool
+
+	w   io.Writer
+	buf []byte
+}
 
-		switch val.Kind() {
-		case reflect.Struct:
-			item, err := unbindItem(val)
-			if err != nil {
-				return "", err
-			}
+// NewEncoder returns a new Encoder that writes to w.
+func NewEncoder(w io.Writer) *Encoder {
+	return &Encoder{w: w}
+}
 
-			if err := marshalItem(&w, item); err != nil {
-				return "", err
-			}
-		case reflect.Slice:
-			list, err := unbindList(val)
-			if err != nil {
-				return "", err
-			}
+// Encode writes the SFV serialization of v to the stream, according to the
+// options in e. Nothing is written if v cannot be serialized.
+//
+// Encode reuses an internal buffer between calls, so an Encoder must not be
+// used to Encode from multiple goroutines at once. Encode returns an error if
+// e wasn't created with NewEncoder.
+func (e *Encoder) Encode(v interface{}) error {
+	if e.w == nil {
+		return errors.New("sfv: Encode called on an Encoder without a writer; use NewEncoder")
+	}
 
-			if err := marshalList(&w, list); err != nil {
-				return "", err
-			}
-		case reflect.Map:
-			dict, err := unbindDictionary(val)
-			if err != nil {
-				return "", err
-			}
+	buf, err := e.AppendMarshal(e.buf[:0], v)
+	if err != nil {
+		return err
+	}
 
-			if err := marshalDictionary(&w, dict); err != nil {
-				return "", err
-			}
-		default:
-			return "", fmt.Errorf("unsupported type: %T", v)
-		}
+	e.buf = buf
+	_, err = e.w.Write(buf)
+	return err
+}
+
+// Marshal returns the SFV serialization of v, according to the options in e.
+func (e *Encoder) Marshal(v interface{}) (string, error) {
+	buf, err := e.AppendMarshal(nil, v)
+	if err != nil {
+		return "", err
 	}
 
-	return w.String(), nil
+	return string(buf), nil
+}
+
+// AppendMarshal appends the SFV serialization of v to dst, according to the
+// options in e, and returns the extended buffer. If v cannot be serialized,
+// AppendMarshal returns dst unmodified.
+func (e *Encoder) AppendMarshal(dst []byte, v interface{}) ([]byte, error) {
+	u := unbinder{keyLess: e.KeyLess, tokenStrings: e.TokenStrings}
+
+	field, err := unbind(&u, v)
+	if err != nil {
+		return dst, err
+	}
+
+	w := serializer{buf: dst, spec: e.Spec}
+	if err := marshalField(&w, field); err != nil {
+		return dst, err
+	}
+
+	return w.buf, nil
+}
+
+// marshalField serializes v, which must be an Item, List, or Dictionary.
+func marshalField(w *serializer, v interface{}) error {
+	switch v := v.(type) {
+	case Item:
+		return marshalItem(w, v)
+	case List:
+		return marshalList(w, v)
+	case Dictionary:
+		return marshalDictionary(w, v)
+	default:
+		return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
 }
 
-func marshalItem(w *strings.Builder, v Item) error {
+func marshalItem(w *serializer, v Item) error {
 	if err := marshalBareItem(w, v.BareItem); err != nil {
 		return err
 	}
@@ -86,7 +131,7 @@ func marshalItem(w *strings.Builder, v Item) error {
 	return nil
 }
 
-func marshalList(w *strings.Builder, v []Member) error {
+func marshalList(w *serializer, v []Member) error {
 	for i, m := range v {
 		if m.IsItem {
 			if err := marshalItem(w, m.Item); err != nil {
@@ -99,47 +144,62 @@ func marshalList(w *strings.Builder, v []Member) error {
 		}
 
 		if i != len(v)-1 {
-			fmt.Fprint(w, ", ")
+			w.writeString(", ")
 		}
 	}
 
 	return nil
 }
 
-func marshalDictionary(w *strings.Builder, v Dictionary) error {
+func marshalDictionary(w *serializer, v Dictionary) error {
+	// Each key must be in the map exactly once, or else members would be
+	// silently dropped, invented, or repeated.
+	if len(v.Keys) != len(v.Map) {
+		return fmt.Errorf("%w: dictionary has %d keys, but %d members", ErrInvalidKey, len(v.Keys), len(v.Map))
+	}
+
+	if k, ok := duplicateKey(v.Keys); ok {
+		return fmt.Errorf("%w: dictionary has key %q more than once", ErrInvalidKey, k)
+	}
+
 	for i, k := range v.Keys {
+		m, ok := v.Map[k]
+		if !ok {
+			return fmt.Errorf("%w: dictionary has no member for key %q", ErrInvalidKey, k)
+		}
+
 		if err := marshalKey(w, k); err != nil {
 			return err
 		}
 
-		if v.Map[k].IsItem && v.Map[k].Item.BareItem.Type == BareItemTypeBoolean && v.Map[k].Item.BareItem.Boolean == true {
-			if err := marshalParams(w, v.Map[k].Item.Params); err != nil {
+		if m.IsItem && m.Item.BareItem.isBoolTrue() {
+			if err := marshalParams(w, m.Item.Params); err != nil {
 				return err
 			}
 		} else {
-			fmt.Fprint(w, "=")
+			w.writeByte('=')
 
-			if v.Map[k].IsItem {
-				if err := marshalItem(w, v.Map[k].Item); err != nil {
+			if m.IsItem {
+				if err := marshalItem(w, m.Item); err != nil {
 					return err
 				}
 			} else {
-				if err := marshalInnerList(w, v.Map[k].InnerList); err != nil {
+				if err := marshalInnerList(w, m.InnerList); err != nil {
 					return err
 				}
 			}
 		}
 
 		if i != len(v.Keys)-1 {
-			fmt.Fprint(w, ", ")
+			w.writeString(", ")
 		}
 	}
 
 	return nil
 }
 
-func marshalInnerList(w *strings.Builder, v InnerList) error {
-	fmt.Fprint(w, "(")
+func marshalInnerList(w *serializer, v InnerList) error {
+	w.writeByte('(')
 
 	for i, m := range v.Items {
 		if err := marshalItem(w, m); err != nil {
@@ -147,11 +207,11 @@ func marshalInnerList(w *strings.Builder, v InnerList) error {
 		}
 
 		if i != len(v.Items)-1 {
-			fmt.Fprintf(w, " ")
+			w.writeByte(' ')
 		}
 	}
 
-	fmt.Fprint(w, ")")
+	w.writeByte(')')
 
 	if err := marshalParams(w, v.Params); err != nil {
 		return err
@@ -160,7 +220,7 @@ func marshalInnerList(w *strings.Builder, v InnerList) error {
 	return nil
 }
 
-func marshalBareItem(w *strings.Builder, v BareItem) error {
+func marshalBareItem(w *serializer, v BareItem) error {
 	switch v.Type {
 	case BareItemTypeDecimal:
 		return marshalDecimal(w, v.Decimal)
@@ -174,94 +234,146 @@ func marshalBareItem(w *strings.Builder, v BareItem) error {
 		return marshalByteSequence(w, v.Binary)
 	case BareItemTypeBoolean:
 		return marshalBoolean(w, v.Boolean)
+	case BareItemTypeDate:
+		if w.spec == RFC8941 {
+			return fmt.Errorf("%w: dates are not supported in %v", ErrUnsupportedType, w.spec)
+		}
+
+		return marshalDate(w, v.Date)
+	case BareItemTypeDisplayString:
+		if w.spec == RFC8941 {
+			return fmt.Errorf("%w: display strings are not supported in %v", ErrUnsupportedType, w.spec)
+		}
+
+		return marshalDisplayString(w, v.DisplayString)
 	default:
-		return fmt.Errorf("unsupported bare item type: %v", v)
+		return fmt.Errorf("%w: bare item type: %v", ErrUnsupportedType, v)
 	}
 }
 
-func marshalDecimal(w *strings.Builder, v float64) error {
-	if int64(v) < -999_999_999_999 || int64(v) > 999_999_999_999 {
-		return fmt.Errorf("decimal out of range: %v", v)
+func marshalDecimal(w *serializer, v Decimal) error {
+	if !v.valid() {
+		return fmt.Errorf("%w: %v", ErrDecimalRange, v)
 	}
 
-	// limit to three digits of precision past the decimal
-	v = math.RoundToEven(v*1000) / 1000
-
-	s := strconv.FormatFloat(v, 'f', -1, 64)
-	if !strings.ContainsRune(s, '.') {
-		s += ".0"
-	}
-
-	fmt.Fprint(w, s)
+	w.buf = v.append(w.buf)
 	return nil
 }
 
-func marshalInteger(w *strings.Builder, v int64) error {
+func marshalInteger(w *serializer, v int64) error {
 	if v < -999_999_999_999_999 || v > 999_999_999_999_999 {
-		return fmt.Errorf("integer out of range: %v", v)
+		return fmt.Errorf("%w: %v", ErrIntegerRange, v)
 	}
 
-	fmt.Fprintf(w, "%d", v)
+	w.buf = strconv.AppendInt(w.buf, v, 10)
 	return nil
 }
 
-func marshalString(w *strings.Builder, v string) error {
-	fmt.Fprint(w, "\"")
-	for _, c := range v {
-		if !isVisible(byte(c)) && c != ' ' {
-			return fmt.Errorf("invalid char in string: %c", c)
+func marshalString(w *serializer, v string) error {
+	w.writeByte('"')
+	for i := 0; i < len(v); i++ {
+		if !isVisible(v[i]) && v[i] != ' ' {
+			return fmt.Errorf("%w: invalid char: %q", ErrInvalidString, v[i])
 		}
 
-		if c == '\\' || c == '"' {
-			fmt.Fprintf(w, "\\%s", string(c))
+		if v[i] == '\\' || v[i] == '"' {
+			w.writeByte('\\')
+		}
+
+		w.writeByte(v[i])
+	}
+	w.writeByte('"')
+	return nil
+}
+
+func marshalDisplayString(w *serializer, v string) error {
+	if !utf8.ValidString(v) {
+		return fmt.Errorf("%w: invalid utf-8 in display string: %q", ErrInvalidString, v)
+	}
+
+	w.writeString("%\"")
+	for i := 0; i < len(v); i++ {
+		if v[i] == '%' || v[i] == '"' || (v[i] != ' ' && !isVisible(v[i])) {
+			w.writeByte('%')
+			w.writeByte(lcHexDigits[v[i]>>4])
+			w.writeByte(lcHexDigits[v[i]&0xf])
 		} else {
-			fmt.Fprintf(w, "%s", string(c))
+			w.writeByte(v[i])
 		}
 	}
-	fmt.Fprint(w, "\"")
+	w.writeByte('"')
 	return nil
 }
 
-func marshalToken(w *strings.Builder, v string) error {
-	for i, c := range v {
-		if i == 0 && !isAlpha(byte(c)) && c != '*' {
-			return fmt.Errorf("invalid first char in token: %v", c)
+func marshalToken(w *serializer, v string) error {
+	if v == "" {
+		return fmt.Errorf("%w: empty token", ErrInvalidToken)
+	}
+
+	for i := 0; i < len(v); i++ {
+		if i == 0 && !isAlpha(v[i]) && v[i] != '*' {
+			return fmt.Errorf("%w: invalid first char: %q", ErrInvalidToken, v[i])
 		}
 
-		if i != 0 && !isTChar(byte(c)) && c != ':' && c != '/' {
-			return fmt.Errorf("invalid char in token: %v", c)
+		if i != 0 && !isTChar(v[i]) && v[i] != ':' && v[i] != '/' {
+			return fmt.Errorf("%w: invalid char: %q", ErrInvalidToken, v[i])
 		}
 	}
 
-	fmt.Fprintf(w, "%s", string(v))
+	w.writeString(v)
 	return nil
 }
 
-func marshalByteSequence(w *strings.Builder, v []byte) error {
-	fmt.Fprintf(w, ":%s:", base64.StdEncoding.EncodeToString(v))
+func marshalByteSequence(w *serializer, v []byte) error {
+	w.writeByte(':')
+	w.writeBase64(v)
+	w.writeByte(':')
 	return nil
 }
 
-func marshalBoolean(w *strings.Builder, v bool) error {
-	n := 0
+func marshalBoolean(w *serializer, v bool) error {
 	if v {
-		n = 1
+		w.writeString("?1")
+	} else {
+		w.writeString("?0")
 	}
 
-	fmt.Fprintf(w, "?%d", n)
 	return nil
 }
 
-func marshalParams(w *strings.Builder, v Params) error {
+func marshalDate(w *serializer, v int64) error {
+	if v < -999_999_999_999_999 || v > 999_999_999_999_999 {
+		return fmt.Errorf("%w: date: %v", ErrIntegerRange, v)
+	}
+
+	w.writeByte('@')
+	w.buf = strconv.AppendInt(w.buf, v, 10)
+	return nil
+}
+
+func marshalParams(w *serializer, v Params) error {
+	if len(v.Keys) != len(v.Map) {
+		return fmt.Errorf("%w: params have %d keys, but %d values", ErrInvalidKey, len(v.Keys), len(v.Map))
+	}
+
+	if k, ok := duplicateKey(v.Keys); ok {
+		return fmt.Errorf("%w: params have key %q more than once", ErrInvalidKey, k)
+	}
+
 	for _, k := range v.Keys {
-		fmt.Fprintf(w, ";")
+		b, ok := v.Map[k]
+		if !ok {
+			return fmt.Errorf("%w: params have no value for key %q", ErrInvalidKey, k)
+		}
+
+		w.writeByte(';')
 		if err := marshalKey(w, k); err != nil {
 			return err
 		}
 
-		if !v.Map[k].isBoolTrue() {
-			fmt.Fprint(w, "=")
-			if err := marshalBareItem(w, v.Map[k]); err != nil {
+		if !b.isBoolTrue() {
+			w.writeByte('=')
+			if err := marshalBareItem(w, b); err != nil {
 				return err
 			}
 		}
@@ -270,17 +382,52 @@ func marshalParams(w *strings.Builder, v Params) error {
 	return nil
 }
 
-func marshalKey(w *strings.Builder, v string) error {
-	for i, c := range v {
-		if i == 0 && !isLCAlpha(byte(c)) && c != '*' {
-			return fmt.Errorf("invalid first char in key: %c", c)
+// duplicateKey returns a key that appears more than once in keys, if there is
+// one.
+//
+// Most dictionaries and parameter lists are small, so they're checked pairwise,
+// which doesn't allocate. Only larger ones pay for a map.
+func duplicateKey(keys []string) (string, bool) {
+	if len(keys) <= 32 {
+		for i := 1; i < len(keys); i++ {
+			for j := 0; j < i; j++ {
+				if keys[i] == keys[j] {
+					return keys[i], true
+				}
+			}
+		}
+
+		return "", false
+	}
+
+	seen := make(map[string]struct{}, len(keys))
+	for _, k := range keys {
+		if _, ok := seen[k]; ok {
+			return k, true
+		}
+
+		seen[k] = struct{}{}
+	}
+
+	return "", false
+}
+
+func marshalKey(w *serializer, v string) error {
+	if v == "" {
+		return fmt.Errorf("%w: empty key", ErrInvalidKey)
+	}
+
+	for i := 0; i < len(v); i++ {
+		c := v[i]
+		if i == 0 && !isLCAlpha(c) && c != '*' {
+			return fmt.Errorf("%w: invalid first char: %q", ErrInvalidKey, c)
 		}
 
-		if !isLCAlpha(byte(c)) && !isDigit(byte(c)) && c != '_' && c != '-' && c != '.' && c != '*' {
-			return fmt.Errorf("invalid char in key: %c", c)
+		if !isLCAlpha(c) && !isDigit(c) && c != '_' && c != '-' && c != '.' && c != '*' {
+			return fmt.Errorf("%w: invalid char: %q", ErrInvalidKey, c)
 		}
 	}
 
-	fmt.Fprintf(w, "%s", v)
+	w.writeString(v)
 	return nil
 }
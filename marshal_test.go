@@ -1,9 +1,15 @@
 package sfv_test
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ucarion/sfv"
 )
@@ -52,7 +58,7 @@ func ExampleMarshal_raw_list() {
 					Map: map[string]sfv.BareItem{
 						"q": sfv.BareItem{
 							Type:    sfv.BareItemTypeDecimal,
-							Decimal: 0.9,
+							Decimal: sfv.DecimalFromMilli(900),
 						},
 					},
 				},
@@ -70,7 +76,7 @@ func ExampleMarshal_raw_list() {
 					Map: map[string]sfv.BareItem{
 						"q": sfv.BareItem{
 							Type:    sfv.BareItemTypeDecimal,
-							Decimal: 0.5,
+							Decimal: sfv.DecimalFromMilli(500),
 						},
 					},
 				},
@@ -122,9 +128,9 @@ func ExampleMarshal_raw_dict() {
 
 func ExampleMarshal_custom_item() {
 	type contentType struct {
-		MediaType string
-		Charset   string `sfv:"charset"`
-		Boundary  string `sfv:"boundary"`
+		MediaType sfv.Token
+		Charset   sfv.Token `sfv:"charset"`
+		Boundary  sfv.Token `sfv:"boundary"`
 	}
 
 	fmt.Println(sfv.Marshal(contentType{MediaType: "text/html", Charset: "UTF-8"}))
@@ -139,19 +145,382 @@ func ExampleMarshal_custom_item() {
 	// multipart/form-data;charset=UTF-8;boundary=xxx <nil>
 }
 
+func ExampleAppendMarshal() {
+	buf := []byte("Accept-Encoding: ")
+	buf, err := sfv.AppendMarshal(buf, []sfv.Token{"gzip", "br"})
+
+	fmt.Println(string(buf), err)
+	// Output: Accept-Encoding: gzip, br <nil>
+}
+
+func ExampleNewEncoder() {
+	e := sfv.NewEncoder(os.Stdout)
+	fmt.Println(e.Encode(map[string]int{"u": 3}))
+	// Output: u=3<nil>
+}
+
+func ExampleMarshal_raw_date() {
+	item := sfv.Item{
+		BareItem: sfv.BareItem{
+			Type: sfv.BareItemTypeDate,
+			Date: 1659578233,
+		},
+	}
+
+	fmt.Println(sfv.Marshal(item))
+	// Output: @1659578233 <nil>
+}
+
+func ExampleMarshal_raw_display_string() {
+	item := sfv.Item{
+		BareItem: sfv.BareItem{
+			Type:          sfv.BareItemTypeDisplayString,
+			DisplayString: "füü 100%",
+		},
+	}
+
+	fmt.Println(sfv.Marshal(item))
+	// Output: %"f%c3%bc%c3%bc 100%25" <nil>
+}
+
+func ExampleMarshal_custom_item_display_string() {
+	type problem struct {
+		Code    sfv.Token
+		Message string `sfv:"msg,display"`
+	}
+
+	fmt.Println(sfv.Marshal(problem{Code: "not-found", Message: "Datei nicht gefunden: füü.txt"}))
+	// Output: not-found;msg=%"Datei nicht gefunden: f%c3%bc%c3%bc.txt" <nil>
+}
+
 func ExampleMarshal_bare_item() {
 	fmt.Println(sfv.Marshal("foo"))
-	// Output: foo <nil>
+	fmt.Println(sfv.Marshal(sfv.Token("foo")))
+
+	// Output:
+	// "foo" <nil>
+	// foo <nil>
+}
+
+func ExampleMarshal_custom_item_tag_options() {
+	type priority struct {
+		Value       sfv.Token
+		Urgency     int    `sfv:"u,omitdefault,default=3"`
+		Incremental bool   `sfv:"i"`
+		Reason      string `sfv:"reason,string"`
+		Retries     *int   `sfv:"retries"`
+	}
+
+	retries := 0
+	fmt.Println(sfv.Marshal(priority{Value: "a", Urgency: 3}))
+	fmt.Println(sfv.Marshal(priority{Value: "a", Urgency: 0, Incremental: true, Reason: "user action", Retries: &retries}))
+
+	// Output:
+	// a <nil>
+	// a;u=0;i;reason="user action";retries=0 <nil>
+}
+
+func ExampleMarshal_custom_dict_struct() {
+	type priority struct {
+		_           struct{} `sfv:",dictionary"`
+		Urgency     int      `sfv:"u,omitdefault,default=3"`
+		Incremental bool     `sfv:"i"`
+	}
+
+	fmt.Println(sfv.Marshal(priority{Urgency: 3, Incremental: true}))
+	fmt.Println(sfv.Marshal(priority{Urgency: 1}))
+
+	// Output:
+	// i <nil>
+	// u=1 <nil>
+}
+
+func TestMarshal_dict_struct(t *testing.T) {
+	type lang struct {
+		Tags []sfv.Token
+		Q    float64 `sfv:"q"`
+	}
+
+	type data struct {
+		_      struct{}  `sfv:",dictionary"`
+		Str    string    `sfv:"str,string"`
+		Int    int       `sfv:"int"`
+		Bool   bool      `sfv:"bool"`
+		Item   mediaType `sfv:"item"`
+		Inner  []int     `sfv:"inner"`
+		Params lang      `sfv:"params"`
+		Empty  sfv.Token `sfv:"empty"`
+		Skip   int
+	}
+
+	out, err := sfv.Marshal(data{
+		Str:    "a",
+		Int:    1,
+		Bool:   true,
+		Item:   mediaType{Type: "text", Subtype: "html"},
+		Inner:  []int{1, 2},
+		Params: lang{Tags: []sfv.Token{"en"}, Q: 0.5},
+		Skip:   1,
+	})
+
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	want := `str="a", int=1, bool, item="text/html", inner=(1 2), params=(en);q=0.5`
+	if out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+}
+
+func ExampleMarshal_custom_item_rest() {
+	type contentType struct {
+		MediaType sfv.Token
+		Charset   sfv.Token  `sfv:"charset"`
+		Rest      sfv.Params `sfv:",rest"`
+	}
+
+	// Parameters that aren't in the struct survive a round-trip, after the
+	// ones that are.
+	var data contentType
+	fmt.Println(sfv.Unmarshal("text/html;boundary=x;charset=utf-8;q=1", &data))
+
+	data.Charset = "ascii"
+	fmt.Println(sfv.Marshal(data))
+
+	// Output:
+	// <nil>
+	// text/html;charset=ascii;boundary=x;q=1 <nil>
+}
+
+func ExampleMarshal_custom_item_embedded() {
+	// Parameters shared by several headers can live in their own struct.
+	type TraceParams struct {
+		TraceID sfv.Token `sfv:"trace"`
+		SpanID  sfv.Token `sfv:"span"`
+	}
+
+	type cacheStatus struct {
+		TraceParams
+		Hit   bool      `sfv:"hit"`
+		Cache sfv.Token `sfv:",value"`
+	}
+
+	fmt.Println(sfv.Marshal(cacheStatus{TraceParams: TraceParams{TraceID: "abc"}, Hit: true, Cache: "ExampleCache"}))
+
+	// Output:
+	// ExampleCache;trace=abc;hit <nil>
+}
+
+func TestMarshal_embedded(t *testing.T) {
+	type Trace struct {
+		ID   sfv.Token `sfv:"trace"`
+		Span sfv.Token `sfv:"span"`
+	}
+
+	type embedded struct {
+		Trace
+		Value sfv.Token
+	}
+
+	type embeddedPtr struct {
+		*Trace
+		Value sfv.Token
+	}
+
+	type shadowed struct {
+		Trace
+		Value sfv.Token
+		Span  int `sfv:"span"`
+	}
+
+	type explicit struct {
+		Trace
+		A     sfv.Token `sfv:"a"`
+		B     sfv.Token
+		Value []int `sfv:",value"`
+	}
+
+	type dict struct {
+		_ struct{} `sfv:",dictionary"`
+		A int      `sfv:"a"`
+		*Trace
+	}
+
+	type trace struct {
+		ID sfv.Token `sfv:"trace"`
+	}
+
+	type unexported struct {
+		trace
+		Value sfv.Token
+	}
+
+	type unexportedPtr struct {
+		*trace
+		Value sfv.Token
+	}
+
+	type unexportedTagged struct {
+		Value sfv.Token
+		a     int `sfv:"a"`
+	}
+
+	testCases := []struct {
+		In  interface{}
+		Out string
+	}{
+		{In: unexported{trace: trace{ID: "a"}, Value: "x"}, Out: "x;trace=a"},
+		{In: unexportedPtr{trace: &trace{ID: "a"}, Value: "x"}, Out: "x"},
+		{In: unexportedTagged{Value: "x", a: 1}, Out: "x"},
+		{In: embedded{Trace: Trace{ID: "a", Span: "b"}, Value: "x"}, Out: "x;trace=a;span=b"},
+		{In: embeddedPtr{Trace: &Trace{ID: "a"}, Value: "x"}, Out: "x;trace=a"},
+		{In: embeddedPtr{Value: "x"}, Out: "x"},
+		{In: shadowed{Trace: Trace{ID: "a", Span: "b"}, Value: "x", Span: 1}, Out: "x;trace=a;span=1"},
+		{In: []explicit{{Trace: Trace{ID: "a"}, A: "b", B: "c", Value: []int{1, 2}}}, Out: "(1 2);trace=a;a=b"},
+		{In: dict{A: 1, Trace: &Trace{ID: "x"}}, Out: "a=1, trace=x"},
+		{In: dict{A: 1}, Out: "a=1"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(tt.In)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestMarshal_rest(t *testing.T) {
+	type dict struct {
+		_    struct{}       `sfv:",dictionary"`
+		A    int            `sfv:"a"`
+		Rest sfv.Dictionary `sfv:",rest"`
+	}
+
+	var in dict
+	if err := sfv.Unmarshal("b=(1 2), a=1, c", &in); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	out, err := sfv.Marshal(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := "a=1, b=(1 2), c"; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+
+	// Fields take precedence over the rest field.
+	in.Rest.Keys = append(in.Rest.Keys, "a")
+	in.Rest.Map["a"] = sfv.Member{IsItem: true, Item: sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeInteger, Integer: 2}}}
+
+	out, err = sfv.Marshal(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := "a=1, b=(1 2), c"; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+
+	if _, err := sfv.Marshal(struct {
+		Value sfv.Token
+		Rest  map[string]int `sfv:",rest"`
+	}{Value: "x"}); !errors.Is(err, sfv.ErrUnsupportedType) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrUnsupportedType, err)
+	}
+}
+
+func ExampleMarshal_custom_item_pointers() {
+	type language struct {
+		Tag    sfv.Token
+		Weight *float64 `sfv:"q"`
+	}
+
+	zero := 0.0
+	fmt.Println(sfv.Marshal([]language{{Tag: "fr", Weight: &zero}, {Tag: "en"}}))
+
+	// Output:
+	// fr;q=0.0, en <nil>
+}
+
+func TestMarshal_pointers(t *testing.T) {
+	type lang struct {
+		Tags []sfv.Token
+		Q    *float64 `sfv:"q"`
+	}
+
+	type item struct {
+		Value *sfv.Token
+		Int   *int  `sfv:"int"`
+		Bool  *bool `sfv:"bool"`
+	}
+
+	type dict struct {
+		_      struct{} `sfv:",dictionary"`
+		Int    *int     `sfv:"int"`
+		Lang   *lang    `sfv:"lang"`
+		Item   *item    `sfv:"item"`
+		Absent *item    `sfv:"absent"`
+	}
+
+	intp := func(i int) *int { return &i }
+	boolp := func(b bool) *bool { return &b }
+	tokenp := func(s sfv.Token) *sfv.Token { return &s }
+	floatp := func(f float64) *float64 { return &f }
+
+	testCases := []struct {
+		In  interface{}
+		Out string
+	}{
+		{In: intp(1), Out: "1"},
+		{In: &item{Value: tokenp("x")}, Out: "x"},
+		{In: item{Value: tokenp("x"), Int: intp(0), Bool: boolp(false)}, Out: "x;int=0;bool=?0"},
+		{In: []*int{intp(1), intp(2)}, Out: "1, 2"},
+		{In: []*lang{{Tags: []sfv.Token{"a", "b"}, Q: floatp(0)}}, Out: "(a b);q=0.0"},
+		{In: dict{
+			Int:  intp(0),
+			Lang: &lang{Tags: []sfv.Token{"a"}, Q: floatp(1)},
+			Item: &item{Value: tokenp("x"), Int: intp(1)},
+		}, Out: "int=0, lang=(a);q=1.0, item=x;int=1"},
+		{In: dict{}, Out: ""},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(tt.In)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+
+	for _, in := range []interface{}{(*int)(nil), []*int{nil}, item{}} {
+		if _, err := sfv.Marshal(in); !errors.Is(err, sfv.ErrUnsupportedType) {
+			t.Errorf("bad err: want: %v, got: %v", sfv.ErrUnsupportedType, err)
+		}
+	}
 }
 
 func ExampleMarshal_custom_basic_list() {
-	fmt.Println(sfv.Marshal([]string{"foo", "bar", "baz"}))
+	fmt.Println(sfv.Marshal([]sfv.Token{"foo", "bar", "baz"}))
 	// Output: foo, bar, baz <nil>
 }
 
 func ExampleMarshal_custom_list() {
 	type language struct {
-		Tag    string
+		Tag    sfv.Token
 		Weight float64 `sfv:"q"`
 	}
 
@@ -166,9 +535,9 @@ func ExampleMarshal_custom_list() {
 }
 
 func ExampleMarshal_custom_list_with_inner_list() {
-	fmt.Println(sfv.Marshal([][]string{
-		[]string{"gzip", "fr"},
-		[]string{"identity", "fr"},
+	fmt.Println(sfv.Marshal([][]sfv.Token{
+		[]sfv.Token{"gzip", "fr"},
+		[]sfv.Token{"identity", "fr"},
 	}))
 
 	// Output:
@@ -177,17 +546,17 @@ func ExampleMarshal_custom_list_with_inner_list() {
 
 func ExampleMarshal_custom_list_with_inner_list_with_params() {
 	type innerListWithParams struct {
-		Names []string
-		Foo   string `sfv:"foo"`
+		Names []sfv.Token
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	fmt.Println(sfv.Marshal([]innerListWithParams{
 		innerListWithParams{
-			Names: []string{"gzip", "fr"},
+			Names: []sfv.Token{"gzip", "fr"},
 			Foo:   "bar",
 		},
 		innerListWithParams{
-			Names: []string{"identity", "fr"},
+			Names: []sfv.Token{"identity", "fr"},
 			Foo:   "baz",
 		},
 	}))
@@ -198,13 +567,13 @@ func ExampleMarshal_custom_list_with_inner_list_with_params() {
 
 func ExampleMarshal_custom_list_with_inner_list_with_nested_params() {
 	type itemWithParams struct {
-		Name string
-		XXX  string `sfv:"xxx"`
+		Name sfv.Token
+		XXX  sfv.Token `sfv:"xxx"`
 	}
 
 	type innerListWithParams struct {
 		Names []itemWithParams
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	fmt.Println(sfv.Marshal([]innerListWithParams{
@@ -236,7 +605,7 @@ func ExampleMarshal_custom_basic_map() {
 func ExampleMarshal_custom_map() {
 	type thing struct {
 		Value int
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	fmt.Println(sfv.Marshal(map[string]thing{
@@ -248,8 +617,8 @@ func ExampleMarshal_custom_map() {
 }
 
 func ExampleMarshal_custom_map_inner_list() {
-	fmt.Println(sfv.Marshal(map[string][]string{
-		"accept-encoding": []string{"gzip", "br"},
+	fmt.Println(sfv.Marshal(map[string][]sfv.Token{
+		"accept-encoding": []sfv.Token{"gzip", "br"},
 	}))
 
 	// Output:
@@ -258,13 +627,13 @@ func ExampleMarshal_custom_map_inner_list() {
 
 func ExampleMarshal_custom_map_with_inner_list_with_params() {
 	type innerListWithParams struct {
-		Names []string
-		Foo   string `sfv:"foo"`
+		Names []sfv.Token
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	fmt.Println(sfv.Marshal(map[string]innerListWithParams{
 		"a": innerListWithParams{
-			Names: []string{"gzip", "fr"},
+			Names: []sfv.Token{"gzip", "fr"},
 			Foo:   "bar",
 		},
 	}))
@@ -275,13 +644,13 @@ func ExampleMarshal_custom_map_with_inner_list_with_params() {
 
 func ExampleMarshal_custom_map_with_inner_list_with_nested_params() {
 	type itemWithParams struct {
-		Name string
-		XXX  string `sfv:"xxx"`
+		Name sfv.Token
+		XXX  sfv.Token `sfv:"xxx"`
 	}
 
 	type innerListWithParams struct {
 		Names []itemWithParams
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	fmt.Println(sfv.Marshal(map[string]innerListWithParams{
@@ -298,6 +667,11 @@ func ExampleMarshal_custom_map_with_inner_list_with_nested_params() {
 	// a=(gzip;xxx=yyy fr);foo=bar <nil>
 }
 
+func ExampleMarshal_custom_date() {
+	fmt.Println(sfv.Marshal(time.Date(2023, 6, 30, 23, 59, 59, 0, time.UTC)))
+	// Output: @1688169599 <nil>
+}
+
 func ExampleMarshal_list_of_bytes() {
 	fmt.Println(sfv.Marshal([][]byte{
 		[]byte{1, 2, 3, 4},
@@ -355,7 +729,7 @@ func TestMarshal_custom_bare_types(t *testing.T) {
 	}{
 		{Out: "?0", Type: reflect.Bool, Bool: false},
 		{Out: "?1", Type: reflect.Bool, Bool: true},
-		{Out: "foo", Type: reflect.String, String: "foo"},
+		{Out: `"foo"`, Type: reflect.String, String: "foo"},
 		{Out: "3", Type: reflect.Int, Int: 3},
 		{Out: "3", Type: reflect.Int8, Int8: 3},
 		{Out: "3", Type: reflect.Int16, Int16: 3},
@@ -422,3 +796,378 @@ func TestMarshal_custom_bare_types(t *testing.T) {
 		})
 	}
 }
+
+func TestEncoder_spec(t *testing.T) {
+	testCases := []struct {
+		In   sfv.BareItem
+		Spec sfv.Spec
+		Fail bool
+	}{
+		{In: sfv.BareItem{Type: sfv.BareItemTypeDate, Date: 1}, Spec: sfv.RFC9651, Fail: false},
+		{In: sfv.BareItem{Type: sfv.BareItemTypeDate, Date: 1}, Spec: sfv.RFC8941, Fail: true},
+		{In: sfv.BareItem{Type: sfv.BareItemTypeDisplayString, DisplayString: "füü"}, Spec: sfv.RFC9651, Fail: false},
+		{In: sfv.BareItem{Type: sfv.BareItemTypeDisplayString, DisplayString: "füü"}, Spec: sfv.RFC8941, Fail: true},
+		{In: sfv.BareItem{Type: sfv.BareItemTypeInteger, Integer: 1}, Spec: sfv.RFC8941, Fail: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%s %s", tt.Spec, tt.In.Type), func(t *testing.T) {
+			e := sfv.Encoder{Spec: tt.Spec}
+			_, err := e.Marshal(sfv.Item{BareItem: tt.In})
+
+			if tt.Fail && err == nil {
+				t.Errorf("must fail, but err is nil")
+			}
+
+			if !tt.Fail && err != nil {
+				t.Errorf("err: %v", err)
+			}
+		})
+	}
+}
+
+func ExampleEncoder_KeyLess() {
+	// Serialize the "default" member last, and the others in increasing order.
+	e := sfv.Encoder{KeyLess: func(a, b string) bool {
+		if a == "default" || b == "default" {
+			return b == "default" && a != "default"
+		}
+
+		return a < b
+	}}
+
+	fmt.Println(e.Marshal(map[string]int{"default": 1, "b": 2, "a": 3}))
+
+	// Output:
+	// a=3, b=2, default=1 <nil>
+}
+
+func TestMarshal_map_order(t *testing.T) {
+	type key string
+
+	in := map[key]int{}
+	for i := 0; i < 26; i++ {
+		in[key(rune('z'-i))] = i
+	}
+
+	want, err := sfv.Marshal(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if !strings.HasPrefix(want, "a=25, b=24, c=23") || !strings.HasSuffix(want, "z=0") {
+		t.Errorf("keys not sorted: %q", want)
+	}
+
+	for i := 0; i < 10; i++ {
+		if out, err := sfv.Marshal(in); err != nil || out != want {
+			t.Fatalf("unstable output: want: %q, got: %q (err: %v)", want, out, err)
+		}
+	}
+
+	e := sfv.Encoder{KeyLess: func(a, b string) bool { return a > b }}
+	out, err := e.Marshal(map[string]bool{"a": true, "c": true, "b": true})
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := "c, b, a"; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+}
+
+func TestMarshal_time_options(t *testing.T) {
+	type item struct {
+		Value    sfv.Token
+		Duration time.Duration `sfv:"d"`
+		Millis   time.Duration `sfv:"ms,ms"`
+		Date     time.Time     `sfv:"t"`
+		Unix     time.Time     `sfv:"u,unix"`
+	}
+
+	testCases := []struct {
+		In  interface{}
+		Out string
+	}{
+		{In: 90 * time.Second, Out: "90"},
+		{In: []time.Duration{time.Minute, 1500 * time.Millisecond}, Out: "60, 1"},
+		{In: item{Value: "a", Duration: -time.Minute, Millis: 1500 * time.Millisecond, Date: time.Unix(1, 0), Unix: time.Unix(2, 0)}, Out: "a;d=-60;ms=1.5;t=@1;u=2"},
+		{In: struct {
+			Value  sfv.Token
+			Millis time.Duration `sfv:"ms,ms"`
+		}{Value: "a", Millis: 1234567 * time.Microsecond}, Out: "a;ms=1.234"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(tt.In)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestEncoder_TokenStrings(t *testing.T) {
+	type item struct {
+		Value   string
+		Token   sfv.Token         `sfv:"t"`
+		Display sfv.DisplayString `sfv:"d"`
+		Text    mediaType         `sfv:"m"`
+	}
+
+	in := item{Value: "a", Token: "b", Display: "c", Text: mediaType{"text", "html"}}
+
+	out, err := sfv.Marshal(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := `"a";t=b;d=%"c";m="text/html"`; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+
+	e := sfv.Encoder{TokenStrings: true}
+	out, err = e.Marshal(in)
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := `a;t=b;d=%"c";m=text/html`; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+
+	// Strings that aren't valid tokens can't be serialized with TokenStrings.
+	if _, err := e.Marshal("hello world"); !errors.Is(err, sfv.ErrInvalidToken) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrInvalidToken, err)
+	}
+
+	out, err = sfv.Marshal("hello world")
+	if err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if want := `"hello world"`; out != want {
+		t.Errorf("actual != expected: want: %q, got: %q", want, out)
+	}
+}
+
+func TestMarshal_error_kinds(t *testing.T) {
+	testCases := []struct {
+		In   interface{}
+		Kind error
+	}{
+		{In: 1_000_000_000_000_000, Kind: sfv.ErrIntegerRange},
+		{In: uint64(1_000_000_000_000_000), Kind: sfv.ErrIntegerRange},
+		{In: uint64(math.MaxUint64), Kind: sfv.ErrIntegerRange},
+		{In: uint(math.MaxUint64), Kind: sfv.ErrIntegerRange},
+		{In: 1_000_000_000_000.0, Kind: sfv.ErrDecimalRange},
+		{In: sfv.Token("fóo"), Kind: sfv.ErrInvalidToken},
+		{In: sfv.Token("1foo"), Kind: sfv.ErrInvalidToken},
+		{In: "fóo", Kind: sfv.ErrInvalidString},
+		{In: map[string]int{"Foo": 1}, Kind: sfv.ErrInvalidKey},
+		{In: sfv.Token(""), Kind: sfv.ErrInvalidToken},
+		{In: sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeString, String: "\n"}}, Kind: sfv.ErrInvalidString},
+		{In: sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeDisplayString, DisplayString: "\xff"}}, Kind: sfv.ErrInvalidString},
+		{In: sfv.Item{}, Kind: sfv.ErrUnsupportedType},
+		{In: make(chan int), Kind: sfv.ErrUnsupportedType},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%#v", tt.In), func(t *testing.T) {
+			if _, err := sfv.Marshal(tt.In); !errors.Is(err, tt.Kind) {
+				t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+			}
+		})
+	}
+}
+
+func TestMarshal_bind_error(t *testing.T) {
+	type lang struct {
+		Tags []interface{}
+		Q    float64 `sfv:"q"`
+	}
+
+	type dict struct {
+		_    struct{} `sfv:",dictionary"`
+		Lang lang     `sfv:"lang"`
+		Int  uint64   `sfv:"int"`
+	}
+
+	testCases := []struct {
+		In    interface{}
+		Path  string
+		Field string
+		Kind  error
+	}{
+		{In: uint64(math.MaxUint64), Path: "item", Kind: sfv.ErrIntegerRange},
+		{In: []float64{1, math.NaN()}, Path: "list[1]", Kind: sfv.ErrDecimalRange},
+		{In: []lang{{Tags: []interface{}{"a", nil}}}, Path: "list[0][1]", Field: "Tags", Kind: sfv.ErrUnsupportedType},
+		{In: []lang{{Q: 1e12}}, Path: `list[0].params["q"]`, Field: "Q", Kind: sfv.ErrDecimalRange},
+		{In: map[string]interface{}{"a": 1, "b": nil}, Path: `dict["b"]`, Kind: sfv.ErrUnsupportedType},
+		{In: dict{Int: math.MaxUint64}, Path: `dict["int"]`, Field: "Int", Kind: sfv.ErrIntegerRange},
+		{In: dict{Lang: lang{Q: math.Inf(1)}}, Path: `dict["lang"].params["q"]`, Field: "Lang.Q", Kind: sfv.ErrDecimalRange},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Path, func(t *testing.T) {
+			_, err := sfv.Marshal(tt.In)
+
+			var bindErr *sfv.BindError
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("bad err: want: *sfv.BindError, got: %#v", err)
+			}
+
+			if bindErr.Path != tt.Path {
+				t.Errorf("bad path: want: %s, got: %s", tt.Path, bindErr.Path)
+			}
+
+			if bindErr.Field != tt.Field {
+				t.Errorf("bad field: want: %q, got: %q", tt.Field, bindErr.Field)
+			}
+
+			if !errors.Is(err, tt.Kind) {
+				t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+			}
+		})
+	}
+}
+
+func TestAppendMarshal_error(t *testing.T) {
+	buf := []byte("foo")
+	buf, err := sfv.AppendMarshal(buf, []sfv.Token{"bar", "1baz"})
+
+	if err == nil {
+		t.Errorf("must fail, but err is nil")
+	}
+
+	if string(buf) != "foo" {
+		t.Errorf("dst modified: %q", buf)
+	}
+}
+
+func TestAppendMarshal_allocs(t *testing.T) {
+	var dict sfv.Dictionary
+	for i := 0; i < 12; i++ {
+		item := sfv.NewItem(sfv.NewInteger(int64(i)))
+		item.Params.Set("a", sfv.NewBoolean(true))
+		item.Params.Set("b", sfv.NewToken("x"))
+		dict.Set(fmt.Sprintf("k%d", i), sfv.NewItemMember(item))
+	}
+
+	var v interface{} = dict
+	buf := make([]byte, 0, 1024)
+	allocs := testing.AllocsPerRun(100, func() {
+		if _, err := sfv.AppendMarshal(buf[:0], v); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("AppendMarshal allocated %v times, want 0", allocs)
+	}
+}
+
+func TestEncoder_Encode(t *testing.T) {
+	var out strings.Builder
+	e := sfv.NewEncoder(&out)
+
+	if err := e.Encode([]sfv.Token{"gzip", "br"}); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	if err := e.Encode([]sfv.Token{"identity", "1baz"}); err == nil {
+		t.Errorf("must fail, but err is nil")
+	}
+
+	if err := e.Encode(sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeBinary, Binary: []byte{1, 2, 3, 4}}}); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	if out.String() != "gzip, br:AQIDBA==:" {
+		t.Errorf("bad output: %q", out.String())
+	}
+}
+
+func TestEncoder_Encode_no_writer(t *testing.T) {
+	var e sfv.Encoder
+	if err := e.Encode(sfv.Token("gzip")); err == nil {
+		t.Errorf("must fail, but err is nil")
+	}
+}
+
+func benchmarkMarshalValues() []struct {
+	Name  string
+	Value interface{}
+} {
+	var item sfv.Item
+	var list []sfv.Member
+	var dict sfv.Dictionary
+
+	if err := sfv.Unmarshal("text/html;charset=utf-8;q=0.9", &item); err != nil {
+		panic(err)
+	}
+
+	if err := sfv.Unmarshal(`gzip;q=1.0, br;q=0.9, ("a" "b");x=:AQIDBA==:, *;q=0.1`, &list); err != nil {
+		panic(err)
+	}
+
+	if err := sfv.Unmarshal("u=3, i, max-age=604800, sig=:AQIDBA==:", &dict); err != nil {
+		panic(err)
+	}
+
+	return []struct {
+		Name  string
+		Value interface{}
+	}{
+		{Name: "item", Value: item},
+		{Name: "list", Value: list},
+		{Name: "dict", Value: dict},
+	}
+}
+
+func BenchmarkMarshal(b *testing.B) {
+	for _, bb := range benchmarkMarshalValues() {
+		b.Run(bb.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := sfv.Marshal(bb.Value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkAppendMarshal(b *testing.B) {
+	for _, bb := range benchmarkMarshalValues() {
+		b.Run(bb.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			var buf []byte
+			for i := 0; i < b.N; i++ {
+				var err error
+				if buf, err = sfv.AppendMarshal(buf[:0], bb.Value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkEncoder_Encode(b *testing.B) {
+	for _, bb := range benchmarkMarshalValues() {
+		b.Run(bb.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			e := sfv.NewEncoder(ioutil.Discard)
+			for i := 0; i < b.N; i++ {
+				if err := e.Encode(bb.Value); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package sfv
+
+// ItemMarshaler is the interface implemented by types that can convert
+// themselves into an Item.
+//
+// Marshal calls MarshalSFVItem wherever a value of the type is serialized as an
+// item, including as a list member or a dictionary value.
+//
+// Types that don't implement ItemMarshaler, but do implement
+// encoding.TextMarshaler, are serialized as a bare item holding their text,
+// like Go strings: a string by default, a display string if the field is
+// tagged with the "display" option, or a token if Encoder.TokenStrings is set.
+type ItemMarshaler interface {
+	MarshalSFVItem() (Item, error)
+}
+
+// ItemUnmarshaler is the interface implemented by types that can populate
+// themselves from an Item.
+//
+// Unmarshal calls UnmarshalSFVItem wherever an item is bound to a value of the
+// type, including as a list member or a dictionary value.
+//
+// Types that don't implement ItemUnmarshaler, but do implement
+// encoding.TextUnmarshaler, can be bound to strings, as well as display strings
+// if the field is tagged with the "display" option, and tokens if
+// Decoder.TokenStrings is set.
+type ItemUnmarshaler interface {
+	UnmarshalSFVItem(Item) error
+}
+
+// ListMarshaler is the interface implemented by types that can convert
+// themselves into a List.
+type ListMarshaler interface {
+	MarshalSFVList() (List, error)
+}
+
+// ListUnmarshaler is the interface implemented by types that can populate
+// themselves from a List.
+type ListUnmarshaler interface {
+	UnmarshalSFVList(List) error
+}
+
+// DictionaryMarshaler is the interface implemented by types that can convert
+// themselves into a Dictionary.
+type DictionaryMarshaler interface {
+	MarshalSFVDictionary() (Dictionary, error)
+}
+
+// DictionaryUnmarshaler is the interface implemented by types that can
+// populate themselves from a Dictionary.
+type DictionaryUnmarshaler interface {
+	UnmarshalSFVDictionary(Dictionary) error
+}
@@ -0,0 +1,225 @@
+package sfv_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ucarion/sfv"
+)
+
+// etag is an entity tag, represented as a string with an optional "w"
+// parameter marking it as weak.
+type etag struct {
+	Tag  string
+	Weak bool
+}
+
+func (e etag) MarshalSFVItem() (sfv.Item, error) {
+	item := sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeString, String: e.Tag}}
+	if e.Weak {
+		item.Params = sfv.Params{
+			Map:  map[string]sfv.BareItem{"w": {Type: sfv.BareItemTypeBoolean, Boolean: true}},
+			Keys: []string{"w"},
+		}
+	}
+
+	return item, nil
+}
+
+func (e *etag) UnmarshalSFVItem(item sfv.Item) error {
+	if item.BareItem.Type != sfv.BareItemTypeString {
+		return errors.New("etag must be a string")
+	}
+
+	e.Tag = item.BareItem.String
+	e.Weak = item.Params.Map["w"].Boolean
+	return nil
+}
+
+// mediaType is a MIME type, like "text/html".
+type mediaType struct {
+	Type, Subtype string
+}
+
+func (m mediaType) MarshalText() ([]byte, error) {
+	return []byte(m.Type + "/" + m.Subtype), nil
+}
+
+func (m *mediaType) UnmarshalText(text []byte) error {
+	parts := strings.SplitN(string(text), "/", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("invalid media type: %q", text)
+	}
+
+	m.Type, m.Subtype = parts[0], parts[1]
+	return nil
+}
+
+// csv is a list of tokens, which it stores as one comma-separated string.
+type csv string
+
+func (c csv) MarshalSFVList() (sfv.List, error) {
+	var out sfv.List
+	for _, s := range strings.Split(string(c), ",") {
+		out = append(out, sfv.Member{IsItem: true, Item: sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeToken, Token: s}}})
+	}
+
+	return out, nil
+}
+
+func (c *csv) UnmarshalSFVList(l sfv.List) error {
+	var parts []string
+	for _, m := range l {
+		parts = append(parts, m.Item.BareItem.Token)
+	}
+
+	*c = csv(strings.Join(parts, ","))
+	return nil
+}
+
+// counts is a dictionary of integers, which it stores as parallel slices.
+type counts struct {
+	Keys   []string
+	Values []int64
+}
+
+func (c counts) MarshalSFVDictionary() (sfv.Dictionary, error) {
+	out := sfv.Dictionary{Map: map[string]sfv.Member{}}
+	for i, k := range c.Keys {
+		out.Keys = append(out.Keys, k)
+		out.Map[k] = sfv.Member{IsItem: true, Item: sfv.Item{BareItem: sfv.BareItem{Type: sfv.BareItemTypeInteger, Integer: c.Values[i]}}}
+	}
+
+	return out, nil
+}
+
+func (c *counts) UnmarshalSFVDictionary(d sfv.Dictionary) error {
+	for _, k := range d.Keys {
+		c.Keys = append(c.Keys, k)
+		c.Values = append(c.Values, d.Map[k].Item.BareItem.Integer)
+	}
+
+	return nil
+}
+
+func ExampleItemUnmarshaler() {
+	var data []etag
+	fmt.Println(sfv.Unmarshal(`"abc", "def";w`, &data))
+	fmt.Println(data)
+
+	// Output:
+	// <nil>
+	// [{abc false} {def true}]
+}
+
+func ExampleItemMarshaler() {
+	fmt.Println(sfv.Marshal([]etag{{Tag: "abc"}, {Tag: "def", Weak: true}}))
+
+	// Output:
+	// "abc", "def";w <nil>
+}
+
+func Example_textMarshaler() {
+	type contentType struct {
+		MediaType mediaType
+		Charset   string `sfv:"charset"`
+	}
+
+	// Like Go strings, text marshalers are read and written as strings,
+	// unless TokenStrings is set.
+	d := sfv.Decoder{TokenStrings: true}
+	e := sfv.Encoder{TokenStrings: true}
+
+	var data contentType
+	fmt.Println(d.Unmarshal("text/html;charset=utf-8", &data))
+	fmt.Println(data.MediaType.Type, data.MediaType.Subtype, data.Charset)
+
+	data.MediaType.Subtype = "plain"
+	fmt.Println(e.Marshal(data))
+
+	// Output:
+	// <nil>
+	// text html utf-8
+	// text/plain;charset=utf-8 <nil>
+}
+
+func TestMarshal_marshalers(t *testing.T) {
+	testCases := []struct {
+		In  interface{}
+		Out string
+	}{
+		{In: etag{Tag: "a", Weak: true}, Out: `"a";w`},
+		{In: []etag{{Tag: "a"}}, Out: `"a"`},
+		{In: [][]etag{{{Tag: "a"}, {Tag: "b"}}}, Out: `("a" "b")`},
+		{In: map[string]etag{"x": {Tag: "a"}}, Out: `x="a"`},
+		{In: mediaType{Type: "text", Subtype: "html"}, Out: `"text/html"`},
+		{In: []mediaType{{Type: "text", Subtype: "html"}}, Out: `"text/html"`},
+		{In: struct {
+			Value sfv.Token
+			Type  mediaType `sfv:"type"`
+		}{Value: "a", Type: mediaType{Type: "text", Subtype: "html"}}, Out: `a;type="text/html"`},
+		{In: csv("a,b,c"), Out: "a, b, c"},
+		{In: counts{Keys: []string{"b", "a"}, Values: []int64{1, 2}}, Out: "b=1, a=2"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(tt.In)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_unmarshalers(t *testing.T) {
+	testCases := []struct {
+		In  string
+		Out interface{}
+	}{
+		{In: `"a";w`, Out: etag{Tag: "a", Weak: true}},
+		{In: `"a"`, Out: []etag{{Tag: "a"}}},
+		{In: `("a" "b")`, Out: [][]etag{{{Tag: "a"}, {Tag: "b"}}}},
+		{In: `x="a"`, Out: map[string]etag{"x": {Tag: "a"}}},
+		{In: `"text/html"`, Out: mediaType{Type: "text", Subtype: "html"}},
+		{In: `"text/html"`, Out: []mediaType{{Type: "text", Subtype: "html"}}},
+		{In: `a;type="text/html"`, Out: struct {
+			Value sfv.Token
+			Type  mediaType `sfv:"type"`
+		}{Value: "a", Type: mediaType{Type: "text", Subtype: "html"}}},
+		{In: "a, b, c", Out: csv("a,b,c")},
+		{In: "b=1, a=2", Out: counts{Keys: []string{"b", "a"}, Values: []int64{1, 2}}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			out := reflect.New(reflect.TypeOf(tt.Out))
+			if err := sfv.Unmarshal(tt.In, out.Interface()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out.Elem().Interface(), tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out.Elem().Interface())
+			}
+		})
+	}
+}
+
+func TestUnmarshal_unmarshaler_error(t *testing.T) {
+	var data []etag
+	if err := sfv.Unmarshal("abc", &data); err == nil || err.Error() != "list[0]: etag must be a string" {
+		t.Errorf("bad err: %v", err)
+	}
+
+	var m mediaType
+	if err := sfv.Unmarshal("html", &m); err == nil {
+		t.Errorf("must fail, but err is nil")
+	}
+}
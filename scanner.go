@@ -1,8 +1,10 @@
 package sfv
 
 type scanner struct {
-	s string
-	i int
+	s      string
+	i      int
+	spec   Spec
+	limits Limits
 }
 
 func (s *scanner) isEOF() bool {
@@ -11,7 +13,7 @@ func (s *scanner) isEOF() bool {
 
 func (s *scanner) peek() (byte, error) {
 	if s.i == len(s.s) {
-		return 0, s.parseError("unexpected end of SFV input")
+		return 0, s.parseError(ErrUnexpectedEOF, "", "unexpected end of SFV input")
 	}
 
 	return s.s[s.i], nil
@@ -34,27 +36,31 @@ func (s *scanner) mustNext() {
 }
 
 func (s *scanner) skipSP() {
-	for {
-		b, err := s.peek()
-		if err != nil || b != ' ' {
-			break
-		}
-
-		s.next()
+	for !s.isEOF() && s.s[s.i] == ' ' {
+		s.i++
 	}
 }
 
 func (s *scanner) skipOWS() {
-	for {
-		b, err := s.peek()
-		if err != nil || !isOWS(b) {
-			break
-		}
-
-		s.next()
+	for !s.isEOF() && isOWS(s.s[s.i]) {
+		s.i++
 	}
 }
 
-func (s scanner) parseError(msg string) error {
-	return ParseError{Offset: s.i, msg: msg}
+func (s *scanner) parseError(kind error, expected, msg string) error {
+	return s.parseErrorAt(s.i, kind, expected, msg)
+}
+
+func (s *scanner) parseErrorAt(i int, kind error, expected, msg string) error {
+	return &ParseError{Offset: i, Err: kind, Expected: expected, Input: s.s, msg: msg}
+}
+
+// checkStringLength returns an error if a string, display string, or token of
+// length n, starting at i, exceeds the limits on s.
+func (s *scanner) checkStringLength(i, n int) error {
+	if s.limits.MaxStringLength > 0 && n > s.limits.MaxStringLength {
+		return s.parseErrorAt(i, ErrLimitExceeded, "", "string is too long")
+	}
+
+	return nil
 }
@@ -0,0 +1,31 @@
+package sfv
+
+import "encoding/base64"
+
+// serializer accumulates the output of the marshal functions. It appends
+// directly to buf, rather than going through an io.Writer, so that
+// AppendMarshal doesn't need to allocate.
+type serializer struct {
+	buf  []byte
+	spec Spec
+}
+
+func (w *serializer) writeByte(b byte) {
+	w.buf = append(w.buf, b)
+}
+
+func (w *serializer) writeString(s string) {
+	w.buf = append(w.buf, s...)
+}
+
+func (w *serializer) writeBase64(b []byte) {
+	n := base64.StdEncoding.EncodedLen(len(b))
+	if cap(w.buf)-len(w.buf) < n {
+		buf := make([]byte, len(w.buf), 2*cap(w.buf)+n)
+		copy(buf, w.buf)
+		w.buf = buf
+	}
+
+	base64.StdEncoding.Encode(w.buf[len(w.buf):len(w.buf)+n], b)
+	w.buf = w.buf[:len(w.buf)+n]
+}
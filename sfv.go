@@ -23,6 +23,8 @@ type Item struct {
 	Params   Params
 }
 
+// Params holds the parameters of an item or inner list. Map holds their values,
+// and Keys holds their names in order.
 type Params struct {
 	Map  map[string]BareItem
 	Keys []string
@@ -31,13 +33,28 @@ type Params struct {
 type BareItem struct {
 	Type    BareItemType
 	Integer int64
-	Decimal float64
+	Decimal Decimal
 	String  string
 	Token   string
 	Binary  []byte
 	Boolean bool
+	Date    int64
+
+	DisplayString string
 }
 
+// Token is a token bare item, such as text/html or *. Fields of type Token are
+// only parsed from tokens, and are serialized as tokens. Unmarshal stores
+// tokens as a Token when binding to an empty interface, to tell them apart from
+// strings.
+type Token string
+
+// DisplayString is a display string bare item, which can contain any Unicode
+// text. Fields of type DisplayString are only parsed from display strings, and
+// are serialized as display strings. Unmarshal stores display strings as a
+// DisplayString when binding to an empty interface.
+type DisplayString string
+
 func (b BareItem) isBoolTrue() bool {
 	return b.Type == BareItemTypeBoolean && b.Boolean == true
 }
@@ -58,6 +75,10 @@ func (t BareItemType) String() string {
 		return "binary"
 	case BareItemTypeBoolean:
 		return "boolean"
+	case BareItemTypeDate:
+		return "date"
+	case BareItemTypeDisplayString:
+		return "display string"
 	default:
 		return "invalid type"
 	}
@@ -70,4 +91,30 @@ const (
 	BareItemTypeToken
 	BareItemTypeBinary
 	BareItemTypeBoolean
+	BareItemTypeDate
+	BareItemTypeDisplayString
+)
+
+// Spec is a revision of the Structured Field Values specification.
+type Spec int
+
+const (
+	// RFC9651 is the current revision of Structured Field Values. It adds
+	// Dates and Display Strings to the types defined in RFC 8941.
+	RFC9651 Spec = iota
+
+	// RFC8941 is the original revision of Structured Field Values. Dates and
+	// Display Strings are rejected when parsing or serializing under RFC8941.
+	RFC8941
 )
+
+func (s Spec) String() string {
+	switch s {
+	case RFC9651:
+		return "RFC 9651"
+	case RFC8941:
+		return "RFC 8941"
+	default:
+		return "invalid spec"
+	}
+}
@@ -3,6 +3,9 @@ package sfv_test
 import (
 	"encoding/base32"
 	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -237,33 +240,39 @@ func TestUnmarshal_StdTestSuite(t *testing.T) {
 		return
 	}
 
-	for _, tt := range testCases {
-		t.Run(tt.Name, func(t *testing.T) {
-			// Supporting this test case would require that we try multiple
-			// decoders when parsing base64, which is undesirable. We currently
-			// fail on this test case, and it's marked as may_fail in the test
-			// suite.
-			if tt.Name == "bad paddding" { // [sic]
-				return
-			}
-
-			actual, err := unmarshalTestCase(tt)
-
-			if tt.MustFail {
-				if err == nil {
-					t.Errorf("must fail, but err is nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("err: %v", err)
-					return
-				}
-
-				expected := decodeTestCase(tt)
-				if !reflect.DeepEqual(actual, expected) {
-					t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
-					return
-				}
+	for _, spec := range []sfv.Spec{sfv.RFC9651, sfv.RFC8941} {
+		t.Run(spec.String(), func(t *testing.T) {
+			d := sfv.Decoder{Spec: spec}
+
+			for _, tt := range testCases {
+				t.Run(tt.Name, func(t *testing.T) {
+					// Supporting this test case would require that we try
+					// multiple decoders when parsing base64, which is
+					// undesirable. We currently fail on this test case, and
+					// it's marked as may_fail in the test suite.
+					if tt.Name == "bad paddding" { // [sic]
+						return
+					}
+
+					actual, err := unmarshalTestCase(&d, tt)
+
+					if tt.MustFail || (spec == sfv.RFC8941 && usesRFC9651(tt.Expected)) {
+						if err == nil {
+							t.Errorf("must fail, but err is nil")
+						}
+					} else {
+						if err != nil {
+							t.Errorf("err: %v", err)
+							return
+						}
+
+						expected := decodeTestCase(tt)
+						if !reflect.DeepEqual(actual, expected) {
+							t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
+							return
+						}
+					}
+				})
 			}
 		})
 	}
@@ -276,35 +285,41 @@ func TestMarshal_StdTestSuite(t *testing.T) {
 		return
 	}
 
-	for _, tt := range testCases {
-		t.Run(tt.Name, func(t *testing.T) {
-			// If there is no data to marshal, then there is nothing to test
-			// here.
-			if tt.Expected == nil {
-				return
-			}
-
-			actual, err := marshalTestCase(tt)
-
-			if tt.MustFail {
-				if err == nil {
-					t.Errorf("must fail, but err is nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("err: %v", err)
-					return
-				}
-
-				expected := tt.Raw[0]
-				if len(tt.Canonical) > 0 {
-					expected = tt.Canonical[0]
-				}
-
-				if !reflect.DeepEqual(actual, expected) {
-					t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
-					return
-				}
+	for _, spec := range []sfv.Spec{sfv.RFC9651, sfv.RFC8941} {
+		t.Run(spec.String(), func(t *testing.T) {
+			e := sfv.Encoder{Spec: spec}
+
+			for _, tt := range testCases {
+				t.Run(tt.Name, func(t *testing.T) {
+					// If there is no data to marshal, then there is nothing to
+					// test here.
+					if tt.Expected == nil {
+						return
+					}
+
+					actual, err := marshalTestCase(&e, tt)
+
+					if tt.MustFail || (spec == sfv.RFC8941 && usesRFC9651(tt.Expected)) {
+						if err == nil {
+							t.Errorf("must fail, but err is nil")
+						}
+					} else {
+						if err != nil {
+							t.Errorf("err: %v", err)
+							return
+						}
+
+						expected := tt.Raw[0]
+						if len(tt.Canonical) > 0 {
+							expected = tt.Canonical[0]
+						}
+
+						if !reflect.DeepEqual(actual, expected) {
+							t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
+							return
+						}
+					}
+				})
 			}
 		})
 	}
@@ -317,81 +332,72 @@ func TestMarshal_StdSerializationTestSuite(t *testing.T) {
 		return
 	}
 
-	for _, tt := range testCases {
-		t.Run(tt.Name, func(t *testing.T) {
-			actual, err := marshalTestCase(tt)
-
-			if tt.MustFail {
-				if err == nil {
-					t.Errorf("must fail, but err is nil")
-				}
-			} else {
-				if err != nil {
-					t.Errorf("err: %v", err)
-					return
-				}
-
-				expected := ""
-
-				if len(tt.Raw) > 0 {
-					expected = tt.Raw[0]
-				}
-
-				if len(tt.Canonical) > 0 {
-					expected = tt.Canonical[0]
-				}
-
-				if !reflect.DeepEqual(actual, expected) {
-					t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
-					return
-				}
+	for _, spec := range []sfv.Spec{sfv.RFC9651, sfv.RFC8941} {
+		t.Run(spec.String(), func(t *testing.T) {
+			e := sfv.Encoder{Spec: spec}
+
+			for _, tt := range testCases {
+				t.Run(tt.Name, func(t *testing.T) {
+					actual, err := marshalTestCase(&e, tt)
+
+					if tt.MustFail || (spec == sfv.RFC8941 && usesRFC9651(tt.Expected)) {
+						if err == nil {
+							t.Errorf("must fail, but err is nil")
+						}
+					} else {
+						if err != nil {
+							t.Errorf("err: %v", err)
+							return
+						}
+
+						expected := ""
+
+						if len(tt.Raw) > 0 {
+							expected = tt.Raw[0]
+						}
+
+						if len(tt.Canonical) > 0 {
+							expected = tt.Canonical[0]
+						}
+
+						if !reflect.DeepEqual(actual, expected) {
+							t.Errorf("actual != expected: want: %#v, got: %#v", expected, actual)
+							return
+						}
+					}
+				})
 			}
 		})
 	}
 }
 
-func unmarshalTestCase(tt testCase) (interface{}, error) {
+func unmarshalTestCase(d *sfv.Decoder, tt testCase) (interface{}, error) {
 	switch tt.HeaderType {
 	case "item":
 		var out sfv.Item
-		for _, r := range tt.Raw {
-			if err := sfv.Unmarshal(r, &out); err != nil {
-				return nil, err
-			}
-		}
-
-		return out, nil
+		err := d.UnmarshalLines(tt.Raw, &out)
+		return out, err
 	case "list":
 		var out []sfv.Member
-		for _, r := range tt.Raw {
-			if err := sfv.Unmarshal(r, &out); err != nil {
-				return nil, err
-			}
-		}
-
-		return out, nil
+		err := d.UnmarshalLines(tt.Raw, &out)
+		return out, err
 	case "dictionary":
 		var out sfv.Dictionary
-		for _, r := range tt.Raw {
-			if err := sfv.Unmarshal(r, &out); err != nil {
-				return nil, err
-			}
-		}
-
-		return out, nil
+		err := d.UnmarshalLines(tt.Raw, &out)
+		return out, err
 	default:
 		panic("unknown header type")
 	}
 }
 
-func marshalTestCase(tt testCase) (string, error) {
+func marshalTestCase(e *sfv.Encoder, tt testCase) (string, error) {
 	switch tt.HeaderType {
 	case "item":
-		return sfv.Marshal(decodeItem(tt.Expected))
+		return e.Marshal(decodeItem(tt.Expected))
 	case "list":
-		return sfv.Marshal(decodeList(tt.Expected))
+		return e.Marshal(decodeList(tt.Expected))
 	case "dictionary":
-		return sfv.Marshal(decodeDictionary(tt.Expected))
+		return e.Marshal(decodeDictionary(tt.Expected))
 	default:
 		panic("unknown header type")
 	}
@@ -410,12 +416,35 @@ func decodeTestCase(tt testCase) interface{} {
 	}
 }
 
+// usesRFC9651 reports whether the expected value of a test case contains any
+// bare items that were introduced in RFC 9651.
+func usesRFC9651(v interface{}) bool {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, e := range v {
+			if usesRFC9651(e) {
+				return true
+			}
+		}
+	case map[string]interface{}:
+		return v["__type"] == "date" || v["__type"] == "displaystring"
+	}
+
+	return false
+}
+
 func parseTestFiles(glob string) ([]testCase, error) {
 	paths, err := filepath.Glob(glob)
 	if err != nil {
 		return nil, err
 	}
 
+	// An empty glob almost always means the test suite submodule wasn't
+	// checked out. Passing with zero cases would hide that.
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no files match %s; run git submodule update --init", glob)
+	}
+
 	var out []testCase
 	for _, path := range paths {
 		file, err := os.Open(path)
@@ -530,12 +559,19 @@ func decodeBareItem(v interface{}) sfv.BareItem {
 		return sfv.BareItem{Type: sfv.BareItemTypeString, String: v}
 	case json.Number:
 		if strings.ContainsRune(v.String(), '.') {
-			n, err := v.Float64()
+			r, ok := new(big.Rat).SetString(v.String())
+			if !ok {
+				panic(v)
+			}
+
+			// Some test cases expect serialization to fail because a decimal
+			// is out of range. Stand in a Decimal that is also out of range.
+			d, err := sfv.DecimalFromRat(r)
 			if err != nil {
-				panic(err)
+				d = sfv.DecimalFromMilli(math.MaxInt64)
 			}
 
-			return sfv.BareItem{Type: sfv.BareItemTypeDecimal, Decimal: n}
+			return sfv.BareItem{Type: sfv.BareItemTypeDecimal, Decimal: d}
 		}
 
 		n, err := v.Int64()
@@ -556,6 +592,15 @@ func decodeBareItem(v interface{}) sfv.BareItem {
 			}
 
 			return sfv.BareItem{Type: sfv.BareItemTypeBinary, Binary: b}
+		case "date":
+			n, err := v["value"].(json.Number).Int64()
+			if err != nil {
+				panic(err)
+			}
+
+			return sfv.BareItem{Type: sfv.BareItemTypeDate, Date: n}
+		case "displaystring":
+			return sfv.BareItem{Type: sfv.BareItemTypeDisplayString, DisplayString: v["value"].(string)}
 		default:
 			panic("bad __type")
 		}
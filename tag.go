@@ -0,0 +1,273 @@
+package sfv
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// tag is the parsed form of an "sfv" struct tag. Tags take the form
+// `sfv:"name,option,option..."`.
+//
+// Fields with a non-empty name correspond to parameters. The field with the
+// "value" option holds the bare item or inner list. If there is no such field,
+// the first field without a name holds it instead, whether it's untagged or
+// only carries options (e.g. `sfv:",display"`).
+type tag struct {
+	name        string
+	display     bool   // serialize strings as display strings
+	str         bool   // serialize strings as strings, even with TokenStrings
+	omitDefault bool   // omit the parameter when serializing its default value
+	required    bool   // fail to parse if the parameter is missing
+	dict        bool   // on a "_" field, marks its struct as a dictionary
+	rest        bool   // collects parameters or members not held by other fields
+	value       bool   // holds the bare item or inner list
+	unix        bool   // serialize times as integer Unix seconds, rather than dates
+	ms          bool   // serialize durations as decimal seconds, rather than integer seconds
+	def         string // the serialized default value of the parameter, if any
+}
+
+func parseTag(f reflect.StructField) tag {
+	s := f.Tag.Get("sfv")
+
+	i := strings.IndexByte(s, ',')
+	if i == -1 {
+		return tag{name: s}
+	}
+
+	t := tag{name: s[:i]}
+	for s = s[i+1:]; s != ""; {
+		// A default may itself contain commas, for instance in a string, so
+		// it always runs to the end of the tag.
+		if strings.HasPrefix(s, "default=") {
+			t.def = s[len("default="):]
+			break
+		}
+
+		var opt string
+		if i = strings.IndexByte(s, ','); i == -1 {
+			opt, s = s, ""
+		} else {
+			opt, s = s[:i], s[i+1:]
+		}
+
+		switch opt {
+		case "display":
+			t.display = true
+		case "string":
+			t.str = true
+		case "omitdefault":
+			t.omitDefault = true
+		case "required":
+			t.required = true
+		case "dictionary":
+			t.dict = true
+		case "rest":
+			t.rest = true
+		case "value":
+			t.value = true
+		case "unix":
+			t.unix = true
+		case "ms":
+			t.ms = true
+		}
+	}
+
+	return t
+}
+
+// holdsValue returns whether a field tagged with t is a candidate to hold the
+// bare item or inner list of its struct.
+func (t tag) holdsValue() bool {
+	return t.value || (t.name == "" && !t.dict && !t.rest)
+}
+
+// field is a struct field, along with its parsed tag. Fields of embedded
+// structs are promoted, like they are in Go, so index may have more than one
+// element.
+type field struct {
+	name  string
+	index []int
+	tag   tag
+}
+
+// fieldCache holds the result of structFields for each type it's called with.
+var fieldCache sync.Map // map[reflect.Type][]field
+
+// structFields returns the fields of the struct type t in the order they're
+// declared, with the fields of embedded structs in place of the embedded
+// struct itself. Unexported fields are left out.
+//
+// As in Go, a promoted field is hidden by a shallower field for the same
+// parameter or dictionary member.
+func structFields(t reflect.Type) []field {
+	if fields, ok := fieldCache.Load(t); ok {
+		return fields.([]field)
+	}
+
+	all := appendFields(nil, t, nil, map[reflect.Type]bool{t: true})
+
+	var fields []field
+	for _, f := range all {
+		if f.tag.name == "" || !isHidden(all, f) {
+			fields = append(fields, f)
+		}
+	}
+
+	actual, _ := fieldCache.LoadOrStore(t, fields)
+	return actual.([]field)
+}
+
+// appendFields appends the fields of t to fields, promoting the fields of
+// embedded structs. index is the index of t within the outermost struct, and
+// seen holds the embedded types already being expanded, to avoid recursing
+// forever on types that embed themselves.
+func appendFields(fields []field, t reflect.Type, index []int, seen map[reflect.Type]bool) []field {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := parseTag(f)
+
+		fi := make([]int, len(index)+1)
+		copy(fi, index)
+		fi[len(index)] = i
+
+		if et := embeddedStruct(f, tag); et != nil {
+			if !seen[et] {
+				seen[et] = true
+				fields = appendFields(fields, et, fi, seen)
+				delete(seen, et)
+			}
+
+			continue
+		}
+
+		// As in encoding/json, unexported fields are ignored, except for
+		// embedded structs whose exported fields are promoted.
+		if f.PkgPath != "" {
+			continue
+		}
+
+		fields = append(fields, field{name: f.Name, index: fi, tag: tag})
+	}
+
+	return fields
+}
+
+// embeddedStruct returns the struct type whose fields f promotes, or nil if f
+// isn't an embedded struct. Embedded structs with a name or option in their
+// tag are treated like any other field.
+func embeddedStruct(f reflect.StructField, t tag) reflect.Type {
+	if !f.Anonymous || t != (tag{}) {
+		return nil
+	}
+
+	typ := f.Type
+	if typ.Kind() == reflect.Ptr {
+		// Pointers to unexported structs can't be allocated when binding.
+		if f.PkgPath != "" {
+			return nil
+		}
+
+		typ = typ.Elem()
+	}
+
+	if typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	return typ
+}
+
+// isHidden returns whether f is hidden by a shallower field in fields with the
+// same name, or an earlier one at the same depth.
+func isHidden(fields []field, f field) bool {
+	for _, g := range fields {
+		if g.tag.name == f.tag.name && len(g.index) < len(f.index) {
+			return true
+		}
+	}
+
+	for _, g := range fields {
+		if g.tag.name == f.tag.name && len(g.index) == len(f.index) {
+			// g is either f itself, or is declared before f.
+			return !reflect.DeepEqual(g.index, f.index)
+		}
+	}
+
+	return false
+}
+
+// valueField returns the field of t that holds the bare item or inner list.
+// This is the field with the "value" option or, if there's no such field, the
+// first unnamed field declared directly on t.
+func valueField(t reflect.Type) (field, bool) {
+	fields := structFields(t)
+	for _, f := range fields {
+		if f.tag.value {
+			return f, true
+		}
+	}
+
+	for _, f := range fields {
+		if len(f.index) == 1 && f.tag.holdsValue() {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// restField returns the field of t with the "rest" option, if there is one.
+func restField(t reflect.Type) (field, bool) {
+	for _, f := range structFields(t) {
+		if f.tag.rest {
+			return f, true
+		}
+	}
+
+	return field{}, false
+}
+
+// hasField returns whether t has a field for the parameter or dictionary
+// member called name.
+func hasField(t reflect.Type, name string) bool {
+	for _, f := range structFields(t) {
+		if f.tag.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isDictionaryStruct returns whether t is a struct that corresponds to a
+// dictionary, rather than an item. Such structs are marked with a blank field
+// carrying the "dictionary" option:
+//
+//	_ struct{} `sfv:",dictionary"`
+func isDictionaryStruct(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if f := t.Field(i); f.Name == "_" && parseTag(f).dict {
+			return true
+		}
+	}
+
+	return false
+}
+
+// defaultValue parses the default value of t.
+func (t tag) defaultValue() (BareItem, error) {
+	s := scanner{s: t.def}
+
+	i, err := parseBareItem(&s)
+	if err != nil {
+		return BareItem{}, fmt.Errorf("invalid default for %s: %w", t.name, err)
+	}
+
+	if !s.isEOF() {
+		return BareItem{}, fmt.Errorf("invalid default for %s: %w", t.name, s.parseError(ErrTrailingCharacters, "end of input", "illegal trailing characters"))
+	}
+
+	return i, nil
+}
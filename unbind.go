@@ -1,15 +1,173 @@
 package sfv
 
 import (
+	"encoding"
 	"fmt"
 	"reflect"
+	"sort"
+	"time"
 )
 
-func unbindItem(v reflect.Value) (Item, error) {
+// unbinder holds the options for converting Go values into SFV data.
+type unbinder struct {
+	keyLess      func(a, b string) bool
+	tokenStrings bool
+}
+
+// sortKeys sorts the keys of a map according to the options in u.
+func (u *unbinder) sortKeys(keys []string) {
+	if u.keyLess != nil {
+		sort.SliceStable(keys, func(i, j int) bool { return u.keyLess(keys[i], keys[j]) })
+	} else {
+		sort.Strings(keys)
+	}
+}
+
+// unbind converts v into an Item, List, or Dictionary, depending on the type of
+// v.
+func unbind(u *unbinder, v interface{}) (interface{}, error) {
+	switch v := v.(type) {
+	case Item, List, Dictionary:
+		return v, nil
+	case ItemMarshaler:
+		return v.MarshalSFVItem()
+	case ListMarshaler:
+		return v.MarshalSFVList()
+	case DictionaryMarshaler:
+		return v.MarshalSFVDictionary()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16,
+		uint32, uint64, float32, float64, Decimal, string, Token, DisplayString,
+		[]byte, time.Time, time.Duration, encoding.TextMarshaler, Parameterized:
+		item, err := unbindItem(u, reflect.ValueOf(v), tag{})
+		if err != nil {
+			return nil, wrapBindError(err, "item", "")
+		}
+
+		return item, nil
+	}
+
+	val := reflect.ValueOf(v)
+
+	switch val.Kind() {
+	case reflect.Ptr:
+		if val.IsNil() {
+			return nil, fmt.Errorf("%w: nil %T", ErrUnsupportedType, v)
+		}
+
+		return unbind(u, val.Elem().Interface())
+	case reflect.Struct:
+		if isDictionaryStruct(val.Type()) {
+			dict, err := unbindDictionary(u, val)
+			if err != nil {
+				return nil, wrapBindError(err, "dict", "")
+			}
+
+			return dict, nil
+		}
+
+		item, err := unbindItem(u, val, tag{})
+		if err != nil {
+			return nil, wrapBindError(err, "item", "")
+		}
+
+		return item, nil
+	case reflect.Slice:
+		list, err := unbindList(u, val)
+		if err != nil {
+			return nil, wrapBindError(err, "list", "")
+		}
+
+		return list, nil
+	case reflect.Map:
+		dict, err := unbindDictionary(u, val)
+		if err != nil {
+			return nil, wrapBindError(err, "dict", "")
+		}
+
+		return dict, nil
+	default:
+		return nil, fmt.Errorf("%w: %T", ErrUnsupportedType, v)
+	}
+}
+
+// elem returns the value v points to or holds, if v is a pointer or an
+// interface. Otherwise, it returns v. It's an error for v to be nil.
+func elem(v reflect.Value) (reflect.Value, error) {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("%w: nil %s", ErrUnsupportedType, v.Type())
+		}
+
+		v = v.Elem()
+	}
+
+	return v, nil
+}
+
+// lookupField returns the field of the struct v at index, or false if the field
+// is promoted through a nil embedded pointer.
+func lookupField(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+// valueOf returns the value of f, the field of the struct v that holds its
+// bare item or inner list, and the tag to serialize it with. It's an error for
+// that to be a nil pointer, or to be promoted through one.
+func valueOf(v reflect.Value, f field) (reflect.Value, tag, error) {
+	fv, ok := lookupField(v, f.index)
+	if !ok {
+		return reflect.Value{}, tag{}, wrapBindError(fmt.Errorf("%w: nil embedded struct", ErrUnsupportedType), "", f.name)
+	}
+
+	t := dynamicTag(fv, f.tag)
+
+	fv, err := elem(fv)
+	if err != nil {
+		return reflect.Value{}, tag{}, wrapBindError(err, "", f.name)
+	}
+
+	return fv, t, nil
+}
+
+// unbindItem converts v into an Item. If v is a struct field, t is its tag.
+func unbindItem(u *unbinder, v reflect.Value, t tag) (Item, error) {
+	t = dynamicTag(v, t)
+
+	v, err := elem(v)
+	if err != nil {
+		return Item{}, err
+	}
+
 	switch v := v.Interface().(type) {
+	case ItemMarshaler:
+		return v.MarshalSFVItem()
+	case Parameterized:
+		m, err := unbindParameterized(u, v)
+		if err != nil {
+			return Item{}, err
+		}
+
+		if !m.IsItem {
+			return Item{}, fmt.Errorf("%w: cannot marshal inner list into item", ErrUnsupportedType)
+		}
+
+		return m.Item, nil
 	case bool, int, int8, int16, int32, int64, uint, uint8, uint16,
-		uint32, uint64, float32, float64, string, []byte:
-		bareItem, err := unbindBareItem(v)
+		uint32, uint64, float32, float64, Decimal, string, Token, DisplayString,
+		[]byte, time.Time, time.Duration, encoding.TextMarshaler:
+		bareItem, err := unbindBareItem(u, v, t)
 		if err != nil {
 			return Item{}, err
 		}
@@ -18,47 +176,44 @@ func unbindItem(v reflect.Value) (Item, error) {
 	}
 
 	if v.Kind() != reflect.Struct {
-		return Item{}, fmt.Errorf("cannot unmarshal to item from %s", v.Type())
+		return Item{}, fmt.Errorf("%w: cannot marshal %s into item", ErrUnsupportedType, v.Type())
 	}
 
-	var bareItem BareItem
-	params := Params{Map: map[string]BareItem{}}
-
-	for i := 0; i < v.NumField(); i++ {
-		if tag, ok := v.Type().Field(i).Tag.Lookup("sfv"); ok {
-			if v.Field(i).IsZero() {
-				continue
-			}
+	params, err := unbindParams(u, v)
+	if err != nil {
+		return Item{}, err
+	}
 
-			bareItem, err := unbindBareItem(v.Field(i).Interface())
-			if err != nil {
-				return Item{}, err
-			}
+	var bareItem BareItem
+	if f, ok := valueField(v.Type()); ok {
+		fv, t, err := valueOf(v, f)
+		if err != nil {
+			return Item{}, err
+		}
 
-			params.Keys = append(params.Keys, tag)
-			params.Map[tag] = bareItem
-		} else {
-			var err error
-			bareItem, err = unbindBareItem(v.Field(i).Interface())
-			if err != nil {
-				return Item{}, err
-			}
+		bareItem, err = unbindBareItem(u, fv.Interface(), t)
+		if err != nil {
+			return Item{}, wrapBindError(err, "", f.name)
 		}
 	}
 
 	return Item{BareItem: bareItem, Params: params}, nil
 }
 
-func unbindList(v reflect.Value) (List, error) {
+func unbindList(u *unbinder, v reflect.Value) (List, error) {
+	if m, ok := v.Interface().(ListMarshaler); ok {
+		return m.MarshalSFVList()
+	}
+
 	if v.Kind() != reflect.Slice {
-		return List{}, fmt.Errorf("cannot unmarshal to list from %s", v.Type())
+		return List{}, fmt.Errorf("%w: cannot marshal %s into list", ErrUnsupportedType, v.Type())
 	}
 
 	var out List
 	for i := 0; i < v.Len(); i++ {
-		member, err := unbindMember(v.Index(i))
+		member, err := unbindMember(u, v.Index(i), tag{})
 		if err != nil {
-			return List{}, err
+			return List{}, wrapBindError(err, fmt.Sprintf("[%d]", i), "")
 		}
 
 		out = append(out, member)
@@ -67,32 +222,39 @@ func unbindList(v reflect.Value) (List, error) {
 	return out, nil
 }
 
-func unbindInnerList(v reflect.Value) (InnerList, error) {
+func unbindInnerList(u *unbinder, v reflect.Value) (InnerList, error) {
+	v, err := elem(v)
+	if err != nil {
+		return InnerList{}, err
+	}
+
 	var params Params
+	var name string
 	if v.Kind() == reflect.Struct {
 		var err error
-		params, err = unbindParams(v)
+		params, err = unbindParams(u, v)
 		if err != nil {
 			return InnerList{}, err
 		}
 
-		for j := 0; j < v.NumField(); j++ {
-			if _, ok := v.Type().Field(j).Tag.Lookup("sfv"); !ok {
-				v = v.Field(j)
-				break
+		if f, ok := valueField(v.Type()); ok {
+			name = f.name
+			if v, _, err = valueOf(v, f); err != nil {
+				return InnerList{}, err
 			}
 		}
 	}
 
 	if v.Kind() != reflect.Slice {
-		return InnerList{}, fmt.Errorf("cannot unmarshal to inner list from %s", v.Type())
+		err := fmt.Errorf("%w: cannot marshal %s into inner list", ErrUnsupportedType, v.Type())
+		return InnerList{}, wrapBindError(err, "", name)
 	}
 
 	var items []Item
 	for i := 0; i < v.Len(); i++ {
-		item, err := unbindItem(v.Index(i))
+		item, err := unbindItem(u, v.Index(i), tag{})
 		if err != nil {
-			return InnerList{}, err
+			return InnerList{}, wrapBindError(err, fmt.Sprintf("[%d]", i), name)
 		}
 
 		items = append(items, item)
@@ -101,21 +263,36 @@ func unbindInnerList(v reflect.Value) (InnerList, error) {
 	return InnerList{Items: items, Params: params}, nil
 }
 
-func unbindDictionary(v reflect.Value) (Dictionary, error) {
-	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
-		return Dictionary{}, fmt.Errorf("cannot unmarshal to map from %s", v.Type())
+func unbindDictionary(u *unbinder, v reflect.Value) (Dictionary, error) {
+	if m, ok := v.Interface().(DictionaryMarshaler); ok {
+		return m.MarshalSFVDictionary()
 	}
 
-	out := Dictionary{Map: map[string]Member{}}
+	if v.Kind() == reflect.Struct && isDictionaryStruct(v.Type()) {
+		return unbindDictionaryStruct(u, v)
+	}
 
+	if v.Kind() != reflect.Map || v.Type().Key().Kind() != reflect.String {
+		return Dictionary{}, fmt.Errorf("%w: cannot marshal %s into dictionary", ErrUnsupportedType, v.Type())
+	}
+
+	// Go doesn't define an order for map iteration, so sort the keys, so that
+	// equal maps always serialize the same way.
+	keys := make([]string, 0, v.Len())
 	iter := v.MapRange()
 	for iter.Next() {
-		member, err := unbindMember(iter.Value())
+		keys = append(keys, iter.Key().String())
+	}
+
+	u.sortKeys(keys)
+
+	out := Dictionary{Map: map[string]Member{}}
+	for _, key := range keys {
+		member, err := unbindMember(u, v.MapIndex(reflect.ValueOf(key).Convert(v.Type().Key())), tag{})
 		if err != nil {
-			return Dictionary{}, err
+			return Dictionary{}, wrapBindError(err, fmt.Sprintf("[%q]", key), "")
 		}
 
-		key := iter.Key().Interface().(string)
 		out.Keys = append(out.Keys, key)
 		out.Map[key] = member
 	}
@@ -123,20 +300,117 @@ func unbindDictionary(v reflect.Value) (Dictionary, error) {
 	return out, nil
 }
 
-func unbindMember(v reflect.Value) (Member, error) {
+// unbindDictionaryStruct converts v, a struct marked with the "dictionary"
+// option, into a Dictionary with a member for each named field, in the order
+// the fields are declared.
+func unbindDictionaryStruct(u *unbinder, v reflect.Value) (Dictionary, error) {
+	out := Dictionary{Map: map[string]Member{}}
+
+	for _, sf := range structFields(v.Type()) {
+		t := sf.tag
+		if t.name == "" {
+			continue
+		}
+
+		// Pointer and interface fields are present if and only if they are
+		// non-nil. The same goes for fields promoted through an embedded
+		// pointer. Like parameters, other fields are omitted if they have
+		// their zero value and no default.
+		f, ok := lookupField(v, sf.index)
+		if !ok {
+			continue
+		}
+
+		if f.Kind() == reflect.Ptr || f.Kind() == reflect.Interface {
+			if f.IsNil() {
+				continue
+			}
+
+			t.omitDefault = false
+		} else if t.def == "" && f.IsZero() {
+			continue
+		}
+
+		member, err := unbindMember(u, f, t)
+		if err != nil {
+			return Dictionary{}, wrapBindError(err, fmt.Sprintf("[%q]", t.name), sf.name)
+		}
+
+		if t.omitDefault && t.def != "" {
+			def, err := t.defaultValue()
+			if err != nil {
+				return Dictionary{}, wrapBindError(err, fmt.Sprintf("[%q]", t.name), sf.name)
+			}
+
+			if reflect.DeepEqual(member, Member{IsItem: true, Item: Item{BareItem: def}}) {
+				continue
+			}
+		}
+
+		out.Keys = append(out.Keys, t.name)
+		out.Map[t.name] = member
+	}
+
+	// Members in the rest field come after all of the others. Fields take
+	// precedence over the rest field if both have the same member.
+	if f, ok := restField(v.Type()); ok {
+		fv, ok := lookupField(v, f.index)
+		if !ok {
+			return out, nil
+		}
+
+		rest, ok := fv.Interface().(Dictionary)
+		if !ok {
+			err := fmt.Errorf("%w: rest field must be sfv.Dictionary, not %s", ErrUnsupportedType, fv.Type())
+			return Dictionary{}, wrapBindError(err, "", f.name)
+		}
+
+		for _, k := range rest.Keys {
+			if _, ok := out.Map[k]; !ok {
+				out.Keys = append(out.Keys, k)
+				out.Map[k] = rest.Map[k]
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// unbindMember converts v into a Member. If v is a struct field, t is its tag.
+func unbindMember(u *unbinder, v reflect.Value, t tag) (Member, error) {
+	t = dynamicTag(v, t)
+
+	v, err := elem(v)
+	if err != nil {
+		return Member{}, err
+	}
+
+	if p, ok := v.Interface().(Parameterized); ok {
+		return unbindParameterized(u, p)
+	}
+
+	// Types that know how to marshal themselves are always items, regardless
+	// of their underlying kind.
+	switch v.Interface().(type) {
+	case ItemMarshaler, encoding.TextMarshaler:
+		item, err := unbindItem(u, v, t)
+		if err != nil {
+			return Member{}, err
+		}
+
+		return Member{IsItem: true, Item: item}, nil
+	}
+
 	isInnerList := v.Type().Kind() == reflect.Slice && v.Type() != reflect.TypeOf([]byte(nil))
 	if !isInnerList && v.Type().Kind() == reflect.Struct {
-		for j := 0; j < v.Type().NumField(); j++ {
-			if _, ok := v.Type().Field(j).Tag.Lookup("sfv"); !ok {
-				isInnerList = v.Type().Field(j).Type.Kind() == reflect.Slice
-				break
-			}
+		if f, ok := valueField(v.Type()); ok {
+			isInnerList = v.Type().FieldByIndex(f.index).Type.Kind() == reflect.Slice
 		}
 	}
 
 	if isInnerList {
 		// v is some sort of slice-of-slices, so we're doing inner lists.
-		innerList, err := unbindInnerList(v)
+		innerList, err := unbindInnerList(u, v)
 		if err != nil {
 			return Member{}, err
 		}
@@ -148,7 +422,7 @@ func unbindMember(v reflect.Value) (Member, error) {
 	}
 
 	// We're doing items.
-	item, err := unbindItem(v)
+	item, err := unbindItem(u, v, t)
 	if err != nil {
 		return Member{}, err
 	}
@@ -160,33 +434,151 @@ func unbindMember(v reflect.Value) (Member, error) {
 
 }
 
-func unbindParams(v reflect.Value) (Params, error) {
+func unbindParams(u *unbinder, v reflect.Value) (Params, error) {
 	if v.Kind() != reflect.Struct {
-		return Params{}, fmt.Errorf("cannot unmarshal to params from %s", v.Type())
+		return Params{}, fmt.Errorf("%w: cannot marshal %s into params", ErrUnsupportedType, v.Type())
 	}
 
 	params := Params{Map: map[string]BareItem{}}
 
-	for i := 0; i < v.NumField(); i++ {
-		if tag, ok := v.Type().Field(i).Tag.Lookup("sfv"); ok {
-			if v.Field(i).IsZero() {
-				continue
-			}
+	for _, f := range structFields(v.Type()) {
+		if f.tag.name == "" {
+			continue
+		}
 
-			bareItem, err := unbindBareItem(v.Field(i).Interface())
-			if err != nil {
-				return Params{}, err
-			}
+		// Fields promoted through a nil embedded pointer are omitted, like
+		// nil pointer fields.
+		fv, ok := lookupField(v, f.index)
+		if !ok {
+			continue
+		}
 
-			params.Keys = append(params.Keys, tag)
-			params.Map[tag] = bareItem
+		param, ok, err := unbindParam(u, fv, f.tag)
+		if err != nil {
+			return Params{}, wrapBindError(err, paramPath(f.tag.name), f.name)
+		}
+
+		if ok {
+			params.Keys = append(params.Keys, f.tag.name)
+			params.Map[f.tag.name] = param
+		}
+	}
+
+	// Parameters in the rest field come after all of the others. Fields take
+	// precedence over the rest field if both have the same parameter.
+	if f, ok := restField(v.Type()); ok {
+		fv, ok := lookupField(v, f.index)
+		if !ok {
+			return params, nil
+		}
+
+		rest, ok := fv.Interface().(Params)
+		if !ok {
+			err := fmt.Errorf("%w: rest field must be sfv.Params, not %s", ErrUnsupportedType, fv.Type())
+			return Params{}, wrapBindError(err, "", f.name)
+		}
+
+		for _, k := range rest.Keys {
+			if _, ok := params.Map[k]; !ok {
+				params.Keys = append(params.Keys, k)
+				params.Map[k] = rest.Map[k]
+			}
 		}
 	}
 
 	return params, nil
 }
 
-func unbindBareItem(v interface{}) (BareItem, error) {
+// unbindParam converts v, a struct field tagged with t, into a parameter value.
+// It returns false if the parameter should be omitted.
+//
+// Parameters without a default are omitted if they have their zero value.
+// Parameters with a default are always present, unless they have the
+// omitdefault option and the same value as their default.
+//
+// Pointer and interface fields are omitted if they are nil, and otherwise
+// always present.
+func unbindParam(u *unbinder, v reflect.Value, t tag) (BareItem, bool, error) {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return BareItem{}, false, nil
+		}
+
+		t = dynamicTag(v, t)
+		v, t.omitDefault = v.Elem(), false
+	} else if t.def == "" && v.IsZero() {
+		return BareItem{}, false, nil
+	}
+
+	bareItem, err := unbindBareItem(u, v.Interface(), t)
+	if err != nil {
+		return BareItem{}, false, err
+	}
+
+	if t.omitDefault && t.def != "" {
+		def, err := t.defaultValue()
+		if err != nil {
+			return BareItem{}, false, err
+		}
+
+		if reflect.DeepEqual(bareItem, def) {
+			return BareItem{}, false, nil
+		}
+	}
+
+	return bareItem, true, nil
+}
+
+// unbindParameterized converts p into a Member, which is an inner list if
+// p.Value is a slice other than a []byte, and an item otherwise.
+func unbindParameterized(u *unbinder, p Parameterized) (Member, error) {
+	keys := p.Keys
+	if keys == nil {
+		keys = make([]string, 0, len(p.Params))
+		for k := range p.Params {
+			keys = append(keys, k)
+		}
+
+		u.sortKeys(keys)
+	} else if len(keys) != len(p.Params) {
+		return Member{}, fmt.Errorf("%w: params have %d keys, but %d values", ErrInvalidKey, len(keys), len(p.Params))
+	}
+
+	params := Params{Map: map[string]BareItem{}, Keys: make([]string, 0, len(keys))}
+	for _, k := range keys {
+		v, ok := p.Params[k]
+		if !ok {
+			return Member{}, fmt.Errorf("%w: params have no value for key %q", ErrInvalidKey, k)
+		}
+
+		param, err := unbindBareItem(u, v, tag{str: true})
+		if err != nil {
+			return Member{}, wrapBindError(err, paramPath(k), "")
+		}
+
+		params.Keys = append(params.Keys, k)
+		params.Map[k] = param
+	}
+
+	m, err := unbindMember(u, reflect.ValueOf(&p.Value).Elem(), tag{})
+	if err != nil {
+		return Member{}, err
+	}
+
+	if m.IsItem {
+		m.Item.Params = params
+	} else {
+		m.InnerList.Params = params
+	}
+
+	return m, nil
+}
+
+func unbindBareItem(u *unbinder, v interface{}, t tag) (BareItem, error) {
+	if !u.tokenStrings {
+		t.str = true
+	}
+
 	switch v := v.(type) {
 	case bool:
 		return BareItem{Type: BareItemTypeBoolean, Boolean: v}, nil
@@ -201,6 +593,12 @@ func unbindBareItem(v interface{}) (BareItem, error) {
 	case int64:
 		return BareItem{Type: BareItemTypeInteger, Integer: int64(v)}, nil
 	case uint:
+		// Unlike the other integer types, uint and uint64 can hold values
+		// that would wrap around to negative when converted to an int64.
+		if v > 999_999_999_999_999 {
+			return BareItem{}, fmt.Errorf("%w: %v", ErrIntegerRange, v)
+		}
+
 		return BareItem{Type: BareItemTypeInteger, Integer: int64(v)}, nil
 	case uint8:
 		return BareItem{Type: BareItemTypeInteger, Integer: int64(v)}, nil
@@ -209,19 +607,65 @@ func unbindBareItem(v interface{}) (BareItem, error) {
 	case uint32:
 		return BareItem{Type: BareItemTypeInteger, Integer: int64(v)}, nil
 	case uint64:
+		if v > 999_999_999_999_999 {
+			return BareItem{}, fmt.Errorf("%w: %v", ErrIntegerRange, v)
+		}
+
 		return BareItem{Type: BareItemTypeInteger, Integer: int64(v)}, nil
 	case float32:
-		return BareItem{Type: BareItemTypeDecimal, Decimal: float64(v)}, nil
+		d, err := DecimalFromFloat64(float64(v))
+		if err != nil {
+			return BareItem{}, err
+		}
+
+		return BareItem{Type: BareItemTypeDecimal, Decimal: d}, nil
 	case float64:
+		d, err := DecimalFromFloat64(v)
+		if err != nil {
+			return BareItem{}, err
+		}
+
+		return BareItem{Type: BareItemTypeDecimal, Decimal: d}, nil
+	case Decimal:
 		return BareItem{Type: BareItemTypeDecimal, Decimal: v}, nil
 	case string:
-		// Do we want to give users a way to coerce serialization to a string
-		// instead of a token? At the time of writing, it's not clear what the
-		// most convenient way to do this for users would be.
+		if t.display {
+			return BareItem{Type: BareItemTypeDisplayString, DisplayString: v}, nil
+		}
+
+		if t.str {
+			return BareItem{Type: BareItemTypeString, String: v}, nil
+		}
+
 		return BareItem{Type: BareItemTypeToken, Token: v}, nil
+	case Token:
+		return BareItem{Type: BareItemTypeToken, Token: string(v)}, nil
+	case DisplayString:
+		return BareItem{Type: BareItemTypeDisplayString, DisplayString: string(v)}, nil
 	case []byte:
 		return BareItem{Type: BareItemTypeBinary, Binary: v}, nil
+	case time.Time:
+		if t.unix {
+			return BareItem{Type: BareItemTypeInteger, Integer: v.Unix()}, nil
+		}
+
+		return BareItem{Type: BareItemTypeDate, Date: v.Unix()}, nil
+	case time.Duration:
+		// Durations are truncated to whole seconds or milliseconds, like times
+		// are truncated to whole seconds. Neither can be out of range.
+		if t.ms {
+			return BareItem{Type: BareItemTypeDecimal, Decimal: DecimalFromMilli(int64(v / time.Millisecond))}, nil
+		}
+
+		return BareItem{Type: BareItemTypeInteger, Integer: int64(v / time.Second)}, nil
+	case encoding.TextMarshaler:
+		text, err := v.MarshalText()
+		if err != nil {
+			return BareItem{}, err
+		}
+
+		return unbindBareItem(u, string(text), t)
 	}
 
-	return BareItem{}, fmt.Errorf("cannot unmarshal from %T", v)
+	return BareItem{}, fmt.Errorf("%w: cannot marshal %T into bare item", ErrUnsupportedType, v)
 }
@@ -1,15 +1,117 @@
 package sfv
 
 import (
-	"bytes"
+	"encoding"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"reflect"
+	"sort"
 	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+// Unmarshal parses s and stores the result in v. It is equivalent to calling
+// Unmarshal on a zero Decoder.
 func Unmarshal(s string, v interface{}) error {
-	scan := scanner{s: s, i: 0}
+	var d Decoder
+	return d.Unmarshal(s, v)
+}
+
+// UnmarshalLines parses a field that was sent as multiple field lines, such as
+// the values of an http.Header, and stores the result in v. It is equivalent to
+// calling UnmarshalLines on a zero Decoder.
+func UnmarshalLines(lines []string, v interface{}) error {
+	var d Decoder
+	return d.UnmarshalLines(lines, v)
+}
+
+// Decoder holds options for parsing SFV data. The zero value is ready to use,
+// and parses according to RFC9651.
+type Decoder struct {
+	// Spec is the revision of the specification to parse according to.
+	Spec Spec
+
+	// Limits restricts the size of the input the Decoder will accept.
+	Limits Limits
+
+	// DisallowUnknownKeys causes parameters and dictionary members that have
+	// no corresponding struct field to be an error, wrapping ErrUnknownKey,
+	// rather than being ignored. This applies to parameters on items and
+	// inner lists bound to non-struct types, too.
+	//
+	// Structs with a field tagged with the "rest" option have no unknown keys.
+	DisallowUnknownKeys bool
+
+	// TokenStrings causes Go strings to be parsed from tokens as well as
+	// strings, as they were before Token was added. Fields with the "string"
+	// option in their struct tag are still only parsed from strings. This
+	// applies to types that implement encoding.TextUnmarshaler, too. By
+	// default, Go strings are only parsed from strings. Use Token to parse
+	// tokens.
+	TokenStrings bool
+}
+
+// Limits restricts the size of the input a Decoder will accept, to defend
+// against hostile input. A zero field means there is no limit.
+//
+// When a limit is exceeded, parsing stops and a *ParseError wrapping
+// ErrLimitExceeded is returned.
+type Limits struct {
+	// MaxLength is the maximum length of the input, in bytes. When parsing
+	// multiple field lines, this is the length of the combined field.
+	MaxLength int
+
+	// MaxMembers is the maximum number of members in a list or dictionary.
+	MaxMembers int
+
+	// MaxInnerListItems is the maximum number of items in an inner list.
+	MaxInnerListItems int
+
+	// MaxParams is the maximum number of parameters on an item or inner list.
+	MaxParams int
+
+	// MaxStringLength is the maximum length of a string, display string, or
+	// token, in bytes.
+	MaxStringLength int
+
+	// MaxBinaryLength is the maximum length of a decoded byte sequence, in
+	// bytes.
+	MaxBinaryLength int
+}
+
+// Unmarshal parses s and stores the result in v, according to the options in
+// d.
+//
+// Wherever possible, keys, tokens, and strings in the result are slices of s,
+// rather than copies of it.
+//
+// To store a bare item in an empty interface, Unmarshal uses the Go type that
+// corresponds to the type of the bare item:
+//
+//	Integer        int64
+//	Decimal        float64
+//	String         string
+//	Token          Token
+//	Byte Sequence  []byte
+//	Boolean        bool
+//	Date           time.Time
+//	Display String DisplayString
+//
+// Inner lists are stored as a []interface{}, and items and inner lists with
+// parameters are stored as a Parameterized. If v is an *interface{}, s is
+// parsed as an item. To parse a list or dictionary into empty interfaces, use
+// a *[]interface{} or a *map[string]interface{}.
+func (d *Decoder) Unmarshal(s string, v interface{}) error {
+	scan := scanner{s: s, i: 0, spec: d.Spec, limits: d.Limits}
+	bind := binder{disallowUnknownKeys: d.DisallowUnknownKeys, tokenStrings: d.TokenStrings}
+
+	if d.Limits.MaxLength > 0 && len(s) > d.Limits.MaxLength {
+		return scan.parseErrorAt(d.Limits.MaxLength, ErrLimitExceeded, "", "input is too long")
+	}
+
 	scan.skipSP()
 
 	switch v := v.(type) {
@@ -26,13 +128,24 @@ func Unmarshal(s string, v interface{}) error {
 			return err
 		}
 
-		*v = append(*v, list...)
+		if *v == nil {
+			*v = list
+		} else {
+			*v = append(*v, list...)
+		}
 	case *Dictionary:
 		dict, err := parseDictionary(&scan)
 		if err != nil {
 			return err
 		}
 
+		// If v is empty, there's nothing to merge dict into, and we can avoid
+		// copying dict over member-by-member.
+		if v.Map == nil && v.Keys == nil {
+			*v = dict
+			break
+		}
+
 		for _, k := range dict.Keys {
 			if _, ok := v.Map[k]; !ok {
 				v.Keys = append(v.Keys, k)
@@ -45,6 +158,36 @@ func Unmarshal(s string, v interface{}) error {
 			v.Map[k] = dict.Map[k]
 		}
 
+	// Types that know how to bind themselves take priority over the reflection
+	// logic below.
+	case ItemUnmarshaler:
+		item, err := parseItem(&scan)
+		if err != nil {
+			return err
+		}
+
+		if err := v.UnmarshalSFVItem(item); err != nil {
+			return err
+		}
+	case ListUnmarshaler:
+		list, err := parseList(&scan)
+		if err != nil {
+			return err
+		}
+
+		if err := v.UnmarshalSFVList(list); err != nil {
+			return err
+		}
+	case DictionaryUnmarshaler:
+		dict, err := parseDictionary(&scan)
+		if err != nil {
+			return err
+		}
+
+		if err := v.UnmarshalSFVDictionary(dict); err != nil {
+			return err
+		}
+
 	// If the user did not provide one of the builtin SFV types, then we are
 	// going to bind SFV data to the user-supplied type. But SFV's grammar is
 	// such that you need to know in advance whether you're parsing an item,
@@ -56,7 +199,8 @@ func Unmarshal(s string, v interface{}) error {
 	// Since we're already checking v.(type), let's see if the user supplied a
 	// "primitive" type. These correspond to an SFV item.
 	case *bool, *int, *int8, *int16, *int32, *int64, *uint, *uint8, *uint16,
-		*uint32, *uint64, *float32, *float64, *string, *[]byte:
+		*uint32, *uint64, *float32, *float64, *Decimal, *string, *Token, *DisplayString,
+		*[]byte, *time.Time, *time.Duration, *interface{}, encoding.TextUnmarshaler:
 		item, err := parseItem(&scan)
 		if err != nil {
 			return err
@@ -64,8 +208,8 @@ func Unmarshal(s string, v interface{}) error {
 
 		val := reflect.ValueOf(v)
 
-		if err := bindItem(item, val.Elem()); err != nil {
-			return err
+		if err := bindItem(&bind, item, val.Elem(), tag{}); err != nil {
+			return wrapBindError(err, "item", "")
 		}
 	default:
 		// The user may have supplied a struct (corresponds to an item), a slice
@@ -75,19 +219,35 @@ func Unmarshal(s string, v interface{}) error {
 
 		// Make sure we can call Elem on val without panicing.
 		if val.Kind() != reflect.Ptr {
-			return fmt.Errorf("unsupported type: %T", v)
+			return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
 		}
 
 		switch val.Elem().Kind() {
 		case reflect.Struct:
-			// Parse as an item, and then bind the item to the given struct.
+			// Structs marked with the "dictionary" option are parsed as a
+			// dictionary, and then each member is bound to a field.
+			if isDictionaryStruct(val.Elem().Type()) {
+				dict, err := parseDictionary(&scan)
+				if err != nil {
+					return err
+				}
+
+				if err := bindDictionary(&bind, dict, val.Elem()); err != nil {
+					return wrapBindError(err, "dict", "")
+				}
+
+				break
+			}
+
+			// Otherwise, parse as an item, and then bind the item to the given
+			// struct.
 			item, err := parseItem(&scan)
 			if err != nil {
 				return err
 			}
 
-			if err := bindItem(item, val.Elem()); err != nil {
-				return err
+			if err := bindItem(&bind, item, val.Elem(), tag{}); err != nil {
+				return wrapBindError(err, "item", "")
 			}
 		case reflect.Slice:
 			// Parse as a list, and then bind the list to the given slice.
@@ -96,8 +256,8 @@ func Unmarshal(s string, v interface{}) error {
 				return err
 			}
 
-			if err := bindList(list, val.Elem()); err != nil {
-				return err
+			if err := bindList(&bind, list, val.Elem()); err != nil {
+				return wrapBindError(err, "list", "")
 			}
 		case reflect.Map:
 			// Parse as a dictionary, and then bind the list to the given map.
@@ -106,32 +266,89 @@ func Unmarshal(s string, v interface{}) error {
 				return err
 			}
 
-			if err := bindDictionary(dict, val.Elem()); err != nil {
-				return err
+			if err := bindDictionary(&bind, dict, val.Elem()); err != nil {
+				return wrapBindError(err, "dict", "")
 			}
 		default:
-			return fmt.Errorf("unsupported type: %T", v)
+			return fmt.Errorf("%w: %T", ErrUnsupportedType, v)
 		}
 	}
 
 	scan.skipSP()
 
 	if !scan.isEOF() {
-		return scan.parseError("illegal trailing characters")
+		return scan.parseError(ErrTrailingCharacters, "end of input", "illegal trailing characters")
 	}
 
 	return nil
 }
 
+// UnmarshalLines parses a field that was sent as multiple field lines, and
+// stores the result in v, according to the options in d.
+//
+// As required by the specification, the lines are combined with ", " before
+// being parsed. Empty lines are ignored. If parsing fails, the returned
+// ParseError indicates which line the error occurred in.
+func (d *Decoder) UnmarshalLines(lines []string, v interface{}) error {
+	var buf strings.Builder
+
+	// For each line that ends up in buf, keep track of where in buf the line
+	// starts, and the index of the line in lines. We'll need this to convert
+	// offsets in buf back to offsets in the original lines.
+	var starts, indices []int
+
+	for i, line := range lines {
+		if strings.Trim(line, " \t") == "" {
+			continue
+		}
+
+		if buf.Len() > 0 {
+			buf.WriteString(", ")
+		}
+
+		starts = append(starts, buf.Len())
+		indices = append(indices, i)
+		buf.WriteString(line)
+	}
+
+	err := d.Unmarshal(buf.String(), v)
+
+	var pe *ParseError
+	if !errors.As(err, &pe) || len(starts) == 0 {
+		return err
+	}
+
+	j := sort.SearchInts(starts, pe.Offset+1) - 1
+	if j < 0 {
+		j = 0
+	}
+
+	pe.Line = indices[j]
+	pe.Offset -= starts[j]
+	pe.Input = lines[pe.Line]
+
+	// An error in the ", " between two lines is reported as being at the end
+	// of the earlier line.
+	if pe.Offset > len(lines[pe.Line]) {
+		pe.Offset = len(lines[pe.Line])
+	}
+
+	return pe
+}
+
 func parseDictionary(s *scanner) (Dictionary, error) {
 	var out Dictionary
 
-	for {
+	for n := 0; ; n++ {
 		b, err := s.peek()
 		if err != nil {
 			break
 		}
 
+		if s.limits.MaxMembers > 0 && n == s.limits.MaxMembers {
+			return Dictionary{}, s.parseError(ErrLimitExceeded, "", "dictionary has too many members")
+		}
+
 		key, err := parseKey(s)
 		if err != nil {
 			return Dictionary{}, err
@@ -183,13 +400,13 @@ func parseDictionary(s *scanner) (Dictionary, error) {
 		}
 
 		if b != ',' {
-			return Dictionary{}, s.parseError("dictionary members must be delimited by ','")
+			return Dictionary{}, s.parseErrorAt(s.i-1, ErrSyntax, "','", "dictionary members must be delimited by ','")
 		}
 
 		s.skipOWS()
 
 		if b, err := s.peek(); err != nil || b == ',' {
-			return Dictionary{}, s.parseError("illegal trailing ','")
+			return Dictionary{}, s.parseError(ErrSyntax, "dictionary member", "illegal trailing ','")
 		}
 	}
 
@@ -203,6 +420,10 @@ func parseList(s *scanner) ([]Member, error) {
 			break
 		}
 
+		if s.limits.MaxMembers > 0 && len(out) == s.limits.MaxMembers {
+			return nil, s.parseError(ErrLimitExceeded, "", "list has too many members")
+		}
+
 		member, err := parseListMember(s)
 		if err != nil {
 			return nil, err
@@ -222,13 +443,13 @@ func parseList(s *scanner) ([]Member, error) {
 		}
 
 		if b != ',' {
-			return nil, s.parseError("list members must be delimited by ','")
+			return nil, s.parseErrorAt(s.i-1, ErrSyntax, "','", "list members must be delimited by ','")
 		}
 
 		s.skipOWS()
 
 		if s.isEOF() {
-			return nil, s.parseError("illegal trailing ',")
+			return nil, s.parseError(ErrSyntax, "list member", "illegal trailing ','")
 		}
 	}
 
@@ -265,7 +486,7 @@ func parseInnerList(s *scanner) (InnerList, error) {
 	}
 
 	if b != '(' {
-		return InnerList{}, s.parseError("inner list must start with '('")
+		return InnerList{}, s.parseErrorAt(s.i-1, ErrSyntax, "'('", "inner list must start with '('")
 	}
 
 	items := []Item{}
@@ -287,6 +508,10 @@ func parseInnerList(s *scanner) (InnerList, error) {
 			return InnerList{Items: items, Params: params}, nil
 		}
 
+		if s.limits.MaxInnerListItems > 0 && len(items) == s.limits.MaxInnerListItems {
+			return InnerList{}, s.parseError(ErrLimitExceeded, "", "inner list has too many items")
+		}
+
 		item, err := parseItem(s)
 		if err != nil {
 			return InnerList{}, err
@@ -300,11 +525,11 @@ func parseInnerList(s *scanner) (InnerList, error) {
 		}
 
 		if b != ' ' && b != ')' {
-			return InnerList{}, s.parseError("inner lists items must be separated by ' '")
+			return InnerList{}, s.parseError(ErrSyntax, "' ' or ')'", "inner lists items must be separated by ' '")
 		}
 	}
 
-	return InnerList{}, s.parseError("unterminated inner list")
+	return InnerList{}, s.parseError(ErrUnexpectedEOF, "')'", "unterminated inner list")
 }
 
 func parseItem(s *scanner) (Item, error) {
@@ -338,22 +563,21 @@ func parseBareItem(s *scanner) (BareItem, error) {
 		return parseByteSequence(s)
 	case b == '?':
 		return parseBoolean(s)
+	case b == '@' && s.spec != RFC8941:
+		return parseDate(s)
+	case b == '%' && s.spec != RFC8941:
+		return parseDisplayString(s)
 	default:
-		return BareItem{}, fmt.Errorf("invalid start of bare item")
+		return BareItem{}, s.parseError(ErrSyntax, "bare item", "invalid start of bare item")
 	}
 }
 
 func parseParameters(s *scanner) (Params, error) {
 	out := Params{Map: map[string]BareItem{}, Keys: []string{}}
 
-	for {
-		b, err := s.peek()
-		if err != nil {
-			break // not an error; eof can end parameters
-		}
-
-		if b != ';' {
-			break
+	for n := 0; !s.isEOF() && s.s[s.i] == ';'; n++ {
+		if s.limits.MaxParams > 0 && n == s.limits.MaxParams {
+			return Params{}, s.parseError(ErrLimitExceeded, "", "too many parameters")
 		}
 
 		s.mustNext()
@@ -365,8 +589,7 @@ func parseParameters(s *scanner) (Params, error) {
 		}
 
 		var value BareItem
-		b, err = s.peek()
-		if err != nil || b != '=' {
+		if s.isEOF() || s.s[s.i] != '=' {
 			// not an error; this just means that the param doesn't have a
 			// value, so we use the default value instead
 			value = BareItem{Type: BareItemTypeBoolean, Boolean: true}
@@ -396,23 +619,16 @@ func parseKey(s *scanner) (string, error) {
 	}
 
 	if b != '*' && !isLCAlpha(b) {
-		return "", s.parseError("bad start of key")
+		return "", s.parseError(ErrInvalidKey, "key", "bad start of key")
 	}
 
-	var buf []byte
-	for {
-		b, err := s.peek()
-		if err != nil {
-			return string(buf), nil // not an error; eof can terminate keys without values
-		}
-
-		if b != '_' && b != '-' && b != '.' && b != '*' && !isLCAlpha(b) && !isDigit(b) {
-			return string(buf), nil
-		}
-
-		buf = append(buf, b)
-		s.mustNext()
+	// Keys are sliced directly out of the input, rather than copied.
+	start := s.i
+	for !s.isEOF() && isKeyChar(s.s[s.i]) {
+		s.i++
 	}
+
+	return s.s[start:s.i], nil
 }
 
 func parseBoolean(s *scanner) (BareItem, error) {
@@ -422,7 +638,7 @@ func parseBoolean(s *scanner) (BareItem, error) {
 	}
 
 	if b != '?' {
-		return BareItem{}, s.parseError("boolean must start with '?'")
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'?'", "boolean must start with '?'")
 	}
 
 	b, err = s.next()
@@ -436,14 +652,16 @@ func parseBoolean(s *scanner) (BareItem, error) {
 	case '1':
 		return BareItem{Type: BareItemTypeBoolean, Boolean: true}, nil
 	default:
-		return BareItem{}, s.parseError("boolean value must be '0' or '1'")
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'0' or '1'", "boolean value must be '0' or '1'")
 	}
 }
 
 func parseNumber(s *scanner) (BareItem, error) {
-	isInt := true      // are we parsing an integer, as opposed to a decimal?
-	isPos := true      // what is the sign of the number?
-	numBuf := []byte{} // a buffer of digits to parse
+	isInt := true // are we parsing an integer, as opposed to a decimal?
+	digits := 0   // the number of digits (and '.', if any) seen so far
+	frac := 0     // the number of digits seen after the '.'
+
+	start := s.i
 
 	b, err := s.peek()
 	if err != nil {
@@ -451,86 +669,105 @@ func parseNumber(s *scanner) (BareItem, error) {
 	}
 
 	if b == '-' {
-		isPos = false
 		s.mustNext()
 	}
 
 	// detect an "empty" integer
-	if _, err := s.peek(); err != nil {
+	if b, err := s.peek(); err != nil {
 		return BareItem{}, err
+	} else if !isDigit(b) {
+		return BareItem{}, s.parseError(ErrSyntax, "digit", "number must start with a digit")
 	}
 
-	for {
-		b, err := s.peek()
-		if err != nil {
-			break // eof is a valid way to end a number
-		}
-
-		ok := true
-		switch {
-		case isDigit(b):
-			numBuf = append(numBuf, b)
-			s.mustNext()
-		case b == '.':
-			if isInt {
-				if len(numBuf) > 12 {
-					return BareItem{}, s.parseError("too many digits in number")
-				}
+	for !s.isEOF() {
+		b := s.s[s.i]
 
-				numBuf = append(numBuf, b)
-				isInt = false
-				s.mustNext()
-			} else {
-				ok = false
+		if isDigit(b) {
+			if !isInt {
+				frac++
+			}
+		} else if b == '.' && isInt {
+			if digits > 12 {
+				return BareItem{}, s.parseError(ErrDecimalRange, "at most 12 integer digits", "too many digits in number")
 			}
-		default:
-			ok = false
-		}
 
-		if !ok {
+			isInt = false
+		} else {
 			break
 		}
 
-		if isInt && len(numBuf) > 15 {
-			return BareItem{}, s.parseError("too many digits in number")
+		digits++
+		s.mustNext()
+
+		if isInt && digits > 15 {
+			return BareItem{}, s.parseErrorAt(s.i-1, ErrIntegerRange, "at most 15 digits", "too many digits in number")
 		}
 
-		if !isInt && len(numBuf) > 16 {
-			return BareItem{}, s.parseError("too many digits in number")
+		if !isInt && digits > 16 {
+			return BareItem{}, s.parseErrorAt(s.i-1, ErrDecimalRange, "at most 16 digits", "too many digits in number")
 		}
 	}
 
+	// Now that the number is known to be well-formed, strconv can parse it
+	// directly out of the input, sign and all.
 	if isInt {
-		i, err := strconv.Atoi(string(numBuf))
+		i, err := strconv.ParseInt(s.s[start:s.i], 10, 64)
 		if err != nil {
-			return BareItem{}, err
+			panic(err) // should be unreachable
 		}
 
-		if isPos {
-			return BareItem{Type: BareItemTypeInteger, Integer: int64(i)}, nil
+		return BareItem{Type: BareItemTypeInteger, Integer: i}, nil
+	}
+
+	if frac == 0 {
+		return BareItem{}, s.parseError(ErrSyntax, "digit", "number cannot end in '.'")
+	}
+
+	if frac > 3 {
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrDecimalRange, "at most 3 fractional digits", "too much precision in fractional part of decimal")
+	}
+
+	// Decimals are parsed exactly, as an integer number of thousandths. The
+	// checks above guarantee this fits comfortably in an int64.
+	var n int64
+	for i := start; i < s.i; i++ {
+		if isDigit(s.s[i]) {
+			n = n*10 + int64(s.s[i]-'0')
 		}
+	}
 
-		return BareItem{Type: BareItemTypeInteger, Integer: int64(-i)}, nil
+	for ; frac < 3; frac++ {
+		n *= 10
 	}
 
-	if numBuf[len(numBuf)-1] == '.' {
-		return BareItem{}, s.parseError("number cannot end in '.'")
+	if s.s[start] == '-' {
+		n = -n
 	}
 
-	if len(numBuf)-bytes.Index(numBuf, []byte{'.'}) > 4 {
-		return BareItem{}, s.parseError("too much precision in fractional part of decimal")
+	return BareItem{Type: BareItemTypeDecimal, Decimal: Decimal{milli: n}}, nil
+}
+
+func parseDate(s *scanner) (BareItem, error) {
+	b, err := s.next()
+	if err != nil {
+		return BareItem{}, err
+	}
+
+	if b != '@' {
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'@'", "date must start with '@'")
 	}
 
-	n, err := strconv.ParseFloat(string(numBuf), 64)
+	start := s.i
+	n, err := parseNumber(s)
 	if err != nil {
-		panic(err) // should be unreachable
+		return BareItem{}, err
 	}
 
-	if isPos {
-		return BareItem{Type: BareItemTypeDecimal, Decimal: n}, nil
+	if n.Type != BareItemTypeInteger {
+		return BareItem{}, s.parseErrorAt(start, ErrSyntax, "integer", "date must be an integer")
 	}
 
-	return BareItem{Type: BareItemTypeDecimal, Decimal: -n}, nil
+	return BareItem{Type: BareItemTypeDate, Date: n.Integer}, nil
 }
 
 func parseString(s *scanner) (BareItem, error) {
@@ -540,11 +777,40 @@ func parseString(s *scanner) (BareItem, error) {
 	}
 
 	if b != '"' {
-		return BareItem{}, s.parseError("string must start with '\"'")
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'\"'", "string must start with '\"'")
 	}
 
-	var buf []byte
+	quote := s.i - 1
+
+	// Most strings don't contain any escapes. In that case, the string can be
+	// sliced directly out of the input. Otherwise, we fall through to copying
+	// the string out one byte at a time.
+	start := s.i
+	for !s.isEOF() && s.s[s.i] != '"' && s.s[s.i] != '\\' && (s.s[s.i] == ' ' || isVisible(s.s[s.i])) {
+		s.i++
+	}
+
+	if !s.isEOF() && s.s[s.i] == '"' {
+		s.mustNext()
+		if err := s.checkStringLength(quote, s.i-1-start); err != nil {
+			return BareItem{}, err
+		}
+
+		return BareItem{Type: BareItemTypeString, String: s.s[start : s.i-1]}, nil
+	}
+
+	// The length is checked on every iteration, so that a hostile string can't
+	// make buf grow past the limit before the closing quote is reached.
+	if err := s.checkStringLength(quote, s.i-start); err != nil {
+		return BareItem{}, err
+	}
+
+	buf := []byte(s.s[start:s.i])
 	for {
+		if err := s.checkStringLength(quote, len(buf)); err != nil {
+			return BareItem{}, err
+		}
+
 		b, err := s.next()
 		if err != nil {
 			return BareItem{}, err
@@ -558,44 +824,107 @@ func parseString(s *scanner) (BareItem, error) {
 			}
 
 			if b != '\\' && b != '"' {
-				return BareItem{}, s.parseError("only '\\' and '\"' may be escaped")
+				return BareItem{}, s.parseErrorAt(s.i-1, ErrInvalidString, "'\\' or '\"'", "only '\\' and '\"' may be escaped")
 			}
 
 			buf = append(buf, b)
 		case b == '"':
 			return BareItem{Type: BareItemTypeString, String: string(buf)}, nil
 		case b != ' ' && !isVisible(b):
-			return BareItem{}, s.parseError("strings must contain only spaces or visible ascii")
+			return BareItem{}, s.parseErrorAt(s.i-1, ErrInvalidString, "space or visible ascii", "strings must contain only spaces or visible ascii")
 		default:
 			buf = append(buf, b)
 		}
 	}
 }
 
-func parseToken(s *scanner) (BareItem, error) {
-	b, err := s.peek()
+func parseDisplayString(s *scanner) (BareItem, error) {
+	b, err := s.next()
 	if err != nil {
-		return BareItem{}, err // tokens cannot be empty
+		return BareItem{}, err
 	}
 
-	if b != '*' && !isAlpha(b) {
-		return BareItem{}, s.parseError("invalid start of token")
+	if b != '%' {
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'%'", "display string must start with '%'")
+	}
+
+	start := s.i - 1
+
+	b, err = s.next()
+	if err != nil {
+		return BareItem{}, err
+	}
+
+	if b != '"' {
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "'\"'", "display string must start with '%\"'")
 	}
 
+	// As with strings, the length is checked on every iteration, rather than
+	// only once the closing quote is reached.
 	var buf []byte
 	for {
-		b, err := s.peek()
+		if err := s.checkStringLength(start, len(buf)); err != nil {
+			return BareItem{}, err
+		}
+
+		b, err := s.next()
 		if err != nil {
-			return BareItem{Type: BareItemTypeToken, Token: string(buf)}, nil // not an error; tokens can end at any time
+			return BareItem{}, err
 		}
 
-		if b != ':' && b != '/' && !isTChar(b) {
-			return BareItem{Type: BareItemTypeToken, Token: string(buf)}, nil
+		switch {
+		case b == '%':
+			hi, err := s.next()
+			if err != nil {
+				return BareItem{}, err
+			}
+
+			lo, err := s.next()
+			if err != nil {
+				return BareItem{}, err
+			}
+
+			if !isLCHexDigit(hi) || !isLCHexDigit(lo) {
+				return BareItem{}, s.parseErrorAt(s.i-2, ErrInvalidString, "lowercase hex digit", "percent-encoding in display string must use lowercase hex digits")
+			}
+
+			buf = append(buf, unhex(hi)<<4|unhex(lo))
+		case b == '"':
+			if !utf8.Valid(buf) {
+				return BareItem{}, s.parseErrorAt(s.i-1, ErrInvalidString, "valid utf-8", "display string must be valid utf-8")
+			}
+
+			return BareItem{Type: BareItemTypeDisplayString, DisplayString: string(buf)}, nil
+		case b != ' ' && !isVisible(b):
+			return BareItem{}, s.parseErrorAt(s.i-1, ErrInvalidString, "space or visible ascii", "display strings must contain only spaces or visible ascii")
+		default:
+			buf = append(buf, b)
 		}
+	}
+}
 
-		buf = append(buf, b)
-		s.mustNext()
+func parseToken(s *scanner) (BareItem, error) {
+	b, err := s.peek()
+	if err != nil {
+		return BareItem{}, err // tokens cannot be empty
 	}
+
+	if b != '*' && !isAlpha(b) {
+		return BareItem{}, s.parseError(ErrInvalidToken, "token", "invalid start of token")
+	}
+
+	// Tokens are sliced directly out of the input, rather than copied. Tokens
+	// can end at any time, including at eof.
+	start := s.i
+	for !s.isEOF() && (s.s[s.i] == ':' || s.s[s.i] == '/' || isTChar(s.s[s.i])) {
+		s.i++
+	}
+
+	if err := s.checkStringLength(start, s.i-start); err != nil {
+		return BareItem{}, err
+	}
+
+	return BareItem{Type: BareItemTypeToken, Token: s.s[start:s.i]}, nil
 }
 
 func parseByteSequence(s *scanner) (BareItem, error) {
@@ -605,36 +934,46 @@ func parseByteSequence(s *scanner) (BareItem, error) {
 	}
 
 	if b != ':' {
-		return BareItem{}, s.parseError("byte sequence must start with ':'")
+		return BareItem{}, s.parseErrorAt(s.i-1, ErrSyntax, "':'", "byte sequence must start with ':'")
 	}
 
-	var buf []byte
-	for {
-		b, err := s.next()
-		if err != nil {
-			return BareItem{}, err
-		}
+	start := s.i
+	end := strings.IndexByte(s.s[start:], ':')
+	if end == -1 {
+		s.i = len(s.s)
+		return BareItem{}, s.parseError(ErrUnexpectedEOF, "':'", "unterminated byte sequence")
+	}
 
-		if b == ':' {
-			break
-		}
+	s.i = start + end + 1
 
-		buf = append(buf, b)
+	// Check the limit before decoding, so that hostile input can't make us
+	// allocate a large buffer only to throw it away.
+	if max := s.limits.MaxBinaryLength; max > 0 && decodedLen(s.s[start:start+end]) > max {
+		return BareItem{}, s.parseErrorAt(start-1, ErrLimitExceeded, "", "byte sequence is too long")
 	}
 
-	bytes, err := base64.StdEncoding.DecodeString(string(buf))
+	bytes, err := base64.StdEncoding.DecodeString(s.s[start : start+end])
 	if err != nil {
-		return BareItem{}, err
+		// base64 reports the offset of the invalid data relative to the start
+		// of the data it was given.
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) {
+			start += int(corrupt)
+		}
+
+		return BareItem{}, s.parseErrorAt(start, ErrInvalidBinary, "base64", "invalid base64 in byte sequence")
 	}
 
 	return BareItem{Type: BareItemTypeBinary, Binary: bytes}, nil
 }
 
-type ParseError struct {
-	Offset int
-	msg    string
-}
+// decodedLen returns the number of bytes that the padded base64 in s decodes
+// to, assuming s is valid.
+func decodedLen(s string) int {
+	n := base64.StdEncoding.DecodedLen(len(s))
+	for i := len(s) - 1; i >= 0 && i >= len(s)-2 && s[i] == '='; i-- {
+		n--
+	}
 
-func (pe ParseError) Error() string {
-	return pe.msg
+	return n
 }
@@ -1,9 +1,12 @@
 package sfv_test
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/ucarion/sfv"
 )
@@ -51,13 +54,33 @@ func ExampleUnmarshal_raw_dict() {
 
 	// Output:
 	// <nil>
-	// public {boolean 0 0   [] true}
-	// max-age {integer 604800 0   [] false}
-	// immutable {boolean 0 0   [] true}
+	// public {boolean 0 0.0   [] true 0 }
+	// max-age {integer 604800 0.0   [] false 0 }
+	// immutable {boolean 0 0.0   [] true 0 }
+}
+
+func ExampleUnmarshal_raw_date() {
+	var item sfv.Item
+	fmt.Println(sfv.Unmarshal("@1659578233", &item))
+	fmt.Println(item.BareItem.Type, item.BareItem.Date)
+
+	// Output:
+	// <nil>
+	// date 1659578233
+}
+
+func ExampleUnmarshal_raw_display_string() {
+	var item sfv.Item
+	fmt.Println(sfv.Unmarshal(`%"f%c3%bc%c3%bc"`, &item))
+	fmt.Println(item.BareItem.Type, item.BareItem.DisplayString)
+
+	// Output:
+	// <nil>
+	// display string füü
 }
 
 func ExampleUnmarshal_custom_bare_item() {
-	var data string
+	var data sfv.Token
 	fmt.Println(sfv.Unmarshal("text/html; charset=UTF-8", &data))
 	fmt.Println(data)
 
@@ -68,9 +91,9 @@ func ExampleUnmarshal_custom_bare_item() {
 
 func ExampleUnmarshal_custom_item() {
 	type contentType struct {
-		MediaType string
-		Charset   string `sfv:"charset"`
-		Boundary  string `sfv:"boundary"`
+		MediaType sfv.Token
+		Charset   sfv.Token `sfv:"charset"`
+		Boundary  sfv.Token `sfv:"boundary"`
 	}
 
 	var data1 contentType
@@ -88,8 +111,44 @@ func ExampleUnmarshal_custom_item() {
 	// {multipart/form-data UTF-8 xxx}
 }
 
+func ExampleUnmarshal_custom_item_display_string() {
+	type problem struct {
+		Code    sfv.Token
+		Message string `sfv:"msg,display"`
+	}
+
+	var data problem
+	fmt.Println(sfv.Unmarshal(`not-found;msg=%"Datei nicht gefunden: f%c3%bc%c3%bc.txt"`, &data))
+	fmt.Println(data)
+
+	// Output:
+	// <nil>
+	// {not-found Datei nicht gefunden: füü.txt}
+}
+
+func ExampleUnmarshal_custom_item_tag_options() {
+	type cacheStatus struct {
+		Cache  sfv.Token
+		Hit    bool      `sfv:"hit"`
+		TTL    int       `sfv:"ttl,default=60"`
+		Detail string    `sfv:"detail,string"`
+		Key    sfv.Token `sfv:"key,required"`
+	}
+
+	var data cacheStatus
+	fmt.Println(sfv.Unmarshal(`ExampleCache;hit;key=abc;detail="found, fresh"`, &data))
+	fmt.Println(data)
+
+	fmt.Println(sfv.Unmarshal(`ExampleCache;hit`, &data))
+
+	// Output:
+	// <nil>
+	// {ExampleCache true 60 found, fresh abc}
+	// item.params["key"] (field Key): sfv: missing required parameter
+}
+
 func ExampleUnmarshal_custom_basic_list() {
-	var data []string
+	var data []sfv.Token
 	fmt.Println(sfv.Unmarshal("foo, bar, baz", &data))
 	fmt.Println(data)
 
@@ -100,7 +159,7 @@ func ExampleUnmarshal_custom_basic_list() {
 
 func ExampleUnmarshal_custom_list() {
 	type language struct {
-		Tag    string
+		Tag    sfv.Token
 		Weight float64 `sfv:"q"`
 	}
 
@@ -115,7 +174,7 @@ func ExampleUnmarshal_custom_list() {
 
 func ExampleUnmarshal_custom_list_iterated_calls() {
 	type language struct {
-		Tag    string
+		Tag    sfv.Token
 		Weight float64 `sfv:"q"`
 	}
 
@@ -133,7 +192,7 @@ func ExampleUnmarshal_custom_list_iterated_calls() {
 }
 
 func ExampleUnmarshal_custom_list_with_inner_list() {
-	var data [][]string
+	var data [][]sfv.Token
 	fmt.Println(sfv.Unmarshal("(gzip fr), (identity fr)", &data))
 	fmt.Println(data)
 
@@ -144,8 +203,8 @@ func ExampleUnmarshal_custom_list_with_inner_list() {
 
 func ExampleUnmarshal_custom_list_with_inner_list_with_params() {
 	type innerListWithParams struct {
-		Names []string
-		Foo   string `sfv:"foo"`
+		Names []sfv.Token
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	var data []innerListWithParams
@@ -159,13 +218,13 @@ func ExampleUnmarshal_custom_list_with_inner_list_with_params() {
 
 func ExampleUnmarshal_custom_list_with_inner_list_with_nested_params() {
 	type itemWithParams struct {
-		Name string
-		XXX  string `sfv:"xxx"`
+		Name sfv.Token
+		XXX  sfv.Token `sfv:"xxx"`
 	}
 
 	type innerListWithParams struct {
 		Names []itemWithParams
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	var data []innerListWithParams
@@ -190,7 +249,7 @@ func ExampleUnmarshal_custom_basic_map() {
 func ExampleUnmarshal_custom_map() {
 	type thing struct {
 		Value int
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	var data map[string]thing
@@ -203,7 +262,7 @@ func ExampleUnmarshal_custom_map() {
 }
 
 func ExampleUnmarshal_custom_map_inner_list() {
-	var data map[string][]string
+	var data map[string][]sfv.Token
 	fmt.Println(sfv.Unmarshal("accept-encoding=(gzip br), accept-language=(en fr)", &data))
 	fmt.Println(data)
 
@@ -214,8 +273,8 @@ func ExampleUnmarshal_custom_map_inner_list() {
 
 func ExampleUnmarshal_custom_map_with_inner_list_with_params() {
 	type innerListWithParams struct {
-		Names []string
-		Foo   string `sfv:"foo"`
+		Names []sfv.Token
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	var data map[string]innerListWithParams
@@ -229,13 +288,13 @@ func ExampleUnmarshal_custom_map_with_inner_list_with_params() {
 
 func ExampleUnmarshal_custom_map_with_inner_list_with_nested_params() {
 	type itemWithParams struct {
-		Name string
-		XXX  string `sfv:"xxx"`
+		Name sfv.Token
+		XXX  sfv.Token `sfv:"xxx"`
 	}
 
 	type innerListWithParams struct {
 		Names []itemWithParams
-		Foo   string `sfv:"foo"`
+		Foo   sfv.Token `sfv:"foo"`
 	}
 
 	var data map[string]innerListWithParams
@@ -247,6 +306,119 @@ func ExampleUnmarshal_custom_map_with_inner_list_with_nested_params() {
 	// map[a:{[{gzip yyy} {fr }] bar} b:{[{identity } {fr zzz}] baz}]
 }
 
+func ExampleUnmarshal_custom_date() {
+	var data time.Time
+	fmt.Println(sfv.Unmarshal("@1688169599", &data))
+	fmt.Println(data)
+
+	// Output:
+	// <nil>
+	// 2023-06-30 23:59:59 +0000 UTC
+}
+
+func ExampleUnmarshalLines() {
+	header := http.Header{}
+	header.Add("Accept-Language", "fr-CH, fr;q=0.9")
+	header.Add("Accept-Language", "*;q=0.5")
+
+	type language struct {
+		Tag    sfv.Token
+		Weight float64 `sfv:"q"`
+	}
+
+	var data []language
+	fmt.Println(sfv.UnmarshalLines(header["Accept-Language"], &data))
+	fmt.Println(data)
+
+	// Output:
+	// <nil>
+	// [{fr-CH 0} {fr 0.9} {* 0.5}]
+}
+
+func ExampleUnmarshal_custom_dict_struct() {
+	type priority struct {
+		_           struct{} `sfv:",dictionary"`
+		Urgency     int      `sfv:"u,default=3"`
+		Incremental bool     `sfv:"i"`
+	}
+
+	var data priority
+	fmt.Println(sfv.Unmarshal("i, x=unknown", &data))
+	fmt.Println(data.Urgency, data.Incremental)
+
+	// Output:
+	// <nil>
+	// 3 true
+}
+
+func ExampleUnmarshal_custom_item_rest() {
+	type contentType struct {
+		MediaType sfv.Token
+		Charset   sfv.Token  `sfv:"charset"`
+		Rest      sfv.Params `sfv:",rest"`
+	}
+
+	var data contentType
+	fmt.Println(sfv.Unmarshal("text/html;boundary=x;charset=utf-8;q=1", &data))
+	fmt.Println(data.MediaType, data.Charset, data.Rest.Keys)
+
+	// Output:
+	// <nil>
+	// text/html utf-8 [boundary q]
+}
+
+func ExampleUnmarshal_custom_item_embedded() {
+	// Parameters shared by several headers can live in their own struct.
+	type TraceParams struct {
+		TraceID sfv.Token `sfv:"trace"`
+		SpanID  sfv.Token `sfv:"span"`
+	}
+
+	type cacheStatus struct {
+		TraceParams
+		Hit   bool      `sfv:"hit"`
+		Cache sfv.Token `sfv:",value"`
+	}
+
+	var data cacheStatus
+	fmt.Println(sfv.Unmarshal("ExampleCache;hit;trace=abc;span=def", &data))
+	fmt.Println(data.Cache, data.Hit, data.TraceID, data.SpanID)
+
+	// Output:
+	// <nil>
+	// ExampleCache true abc def
+}
+
+func ExampleDecoder_DisallowUnknownKeys() {
+	type contentType struct {
+		MediaType sfv.Token
+		Charset   sfv.Token `sfv:"charset"`
+	}
+
+	d := sfv.Decoder{DisallowUnknownKeys: true}
+
+	var data contentType
+	fmt.Println(d.Unmarshal("text/html;charset=utf-8;boundary=x", &data))
+
+	// Output:
+	// item.params["boundary"]: sfv: unknown key
+}
+
+func ExampleUnmarshal_custom_item_pointers() {
+	type language struct {
+		Tag    sfv.Token
+		Weight *float64 `sfv:"q"`
+	}
+
+	var data []language
+	fmt.Println(sfv.Unmarshal("fr;q=0.0, en", &data))
+	fmt.Println(*data[0].Weight, data[1].Weight)
+
+	// Output:
+	// <nil>
+	// 0 <nil>
+}
+
 func ExampleUnmarshal_list_of_bytes() {
 	var data [][]byte
 	fmt.Println(sfv.Unmarshal(":AQIDBA==:, :AQIDBA==:", &data))
@@ -300,7 +472,6 @@ func TestUnmarshal_custom_bare_types(t *testing.T) {
 		{In: "?0", Type: reflect.Bool, Bool: false},
 		{In: "?1", Type: reflect.Bool, Bool: true},
 		{In: "?1;foo=bar", Type: reflect.Bool, Bool: true},
-		{In: "foo", Type: reflect.String, String: "foo"},
 		{In: "\"foo\"", Type: reflect.String, String: "foo"},
 		{In: "3", Type: reflect.Int, Int: 3},
 		{In: "3", Type: reflect.Int8, Int8: 3},
@@ -414,3 +585,1102 @@ func TestUnmarshal_custom_bare_types(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshal_invalid_display_strings(t *testing.T) {
+	testCases := []string{
+		`%`,
+		`%"`,
+		`%"abc`,
+		`%abc"`,
+		`%"%C3%BC"`,
+		`%"%c3"`,
+		`%"%g0"`,
+		`%"%c"`,
+		"%\"\t\"",
+		"%\"\u00fc\"",
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt, func(t *testing.T) {
+			var item sfv.Item
+			if err := sfv.Unmarshal(tt, &item); err == nil {
+				t.Errorf("must fail, but err is nil")
+			}
+		})
+	}
+}
+
+func TestUnmarshal_invalid_dates(t *testing.T) {
+	testCases := []string{"@", "@1.5", "@a", "@@1", "@1234567890123456"}
+
+	for _, tt := range testCases {
+		t.Run(tt, func(t *testing.T) {
+			var item sfv.Item
+			if err := sfv.Unmarshal(tt, &item); err == nil {
+				t.Errorf("must fail, but err is nil")
+			}
+		})
+	}
+}
+
+func TestDecoder_spec(t *testing.T) {
+	testCases := []struct {
+		In   string
+		Spec sfv.Spec
+		Fail bool
+	}{
+		{In: "@1659578233", Spec: sfv.RFC9651, Fail: false},
+		{In: "@1659578233", Spec: sfv.RFC8941, Fail: true},
+		{In: `%"f%c3%bc%c3%bc"`, Spec: sfv.RFC9651, Fail: false},
+		{In: `%"f%c3%bc%c3%bc"`, Spec: sfv.RFC8941, Fail: true},
+		{In: "foo;a=@1", Spec: sfv.RFC8941, Fail: true},
+		{In: "foo;a=1", Spec: sfv.RFC8941, Fail: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%s %s", tt.Spec, tt.In), func(t *testing.T) {
+			d := sfv.Decoder{Spec: tt.Spec}
+
+			var item sfv.Item
+			err := d.Unmarshal(tt.In, &item)
+
+			if tt.Fail && err == nil {
+				t.Errorf("must fail, but err is nil")
+			}
+
+			if !tt.Fail && err != nil {
+				t.Errorf("err: %v", err)
+			}
+		})
+	}
+}
+
+func TestDecoder_limits(t *testing.T) {
+	testCases := []struct {
+		In     string
+		Limits sfv.Limits
+		Fail   bool
+		Offset int
+	}{
+		{In: "a, b, c", Limits: sfv.Limits{MaxLength: 7}, Fail: false},
+		{In: "a, b, c", Limits: sfv.Limits{MaxLength: 6}, Fail: true, Offset: 6},
+		{In: "a, b, c", Limits: sfv.Limits{MaxMembers: 3}, Fail: false},
+		{In: "a, b, c", Limits: sfv.Limits{MaxMembers: 2}, Fail: true, Offset: 6},
+		{In: "(a b c)", Limits: sfv.Limits{MaxInnerListItems: 3}, Fail: false},
+		{In: "(a b c)", Limits: sfv.Limits{MaxInnerListItems: 2}, Fail: true, Offset: 5},
+		{In: "a;x;y", Limits: sfv.Limits{MaxParams: 2}, Fail: false},
+		{In: "a;x;y", Limits: sfv.Limits{MaxParams: 1}, Fail: true, Offset: 3},
+		{In: "(a);x;y", Limits: sfv.Limits{MaxParams: 1}, Fail: true, Offset: 5},
+		{In: `"abc"`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: false},
+		{In: `"abc"`, Limits: sfv.Limits{MaxStringLength: 2}, Fail: true, Offset: 0},
+		{In: `a, "a\"bc"`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: true, Offset: 3},
+		{In: `"abcdef`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: true, Offset: 0},
+		{In: `"a\"bcdef`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: true, Offset: 0},
+		{In: `%"abcdef`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: true, Offset: 0},
+		{In: `%"f%c3%bc"`, Limits: sfv.Limits{MaxStringLength: 3}, Fail: false},
+		{In: `%"f%c3%bc"`, Limits: sfv.Limits{MaxStringLength: 2}, Fail: true, Offset: 0},
+		{In: "abc", Limits: sfv.Limits{MaxStringLength: 3}, Fail: false},
+		{In: "abc", Limits: sfv.Limits{MaxStringLength: 2}, Fail: true, Offset: 0},
+		{In: ":AQID:", Limits: sfv.Limits{MaxBinaryLength: 3}, Fail: false},
+		{In: ":AQID:", Limits: sfv.Limits{MaxBinaryLength: 2}, Fail: true, Offset: 0},
+		{In: ":AQI=:", Limits: sfv.Limits{MaxBinaryLength: 2}, Fail: false},
+		{In: ":AQ==:", Limits: sfv.Limits{MaxBinaryLength: 1}, Fail: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			d := sfv.Decoder{Limits: tt.Limits}
+
+			var list []sfv.Member
+			err := d.Unmarshal(tt.In, &list)
+
+			if !tt.Fail {
+				if err != nil {
+					t.Errorf("err: %v", err)
+				}
+
+				return
+			}
+
+			var pe *sfv.ParseError
+			if !errors.As(err, &pe) {
+				t.Fatalf("err is not a *ParseError: %v", err)
+			}
+
+			if !errors.Is(err, sfv.ErrLimitExceeded) {
+				t.Errorf("bad err: want: %v, got: %v", sfv.ErrLimitExceeded, err)
+			}
+
+			if pe.Offset != tt.Offset {
+				t.Errorf("bad offset: want: %d, got: %d", tt.Offset, pe.Offset)
+			}
+		})
+	}
+}
+
+func TestDecoder_limits_dictionary(t *testing.T) {
+	d := sfv.Decoder{Limits: sfv.Limits{MaxMembers: 2}}
+
+	var dict sfv.Dictionary
+	if err := d.Unmarshal("a, b", &dict); err != nil {
+		t.Errorf("err: %v", err)
+	}
+
+	if err := d.Unmarshal("a, b, c", &dict); !errors.Is(err, sfv.ErrLimitExceeded) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrLimitExceeded, err)
+	}
+}
+
+func TestDecoder_DisallowUnknownKeys(t *testing.T) {
+	type item struct {
+		Value sfv.Token
+		A     int `sfv:"a"`
+	}
+
+	type innerList struct {
+		Items []sfv.Token
+		A     int `sfv:"a"`
+	}
+
+	type dict struct {
+		_ struct{} `sfv:",dictionary"`
+		A int      `sfv:"a"`
+	}
+
+	type itemRest struct {
+		Value sfv.Token
+		Rest  sfv.Params `sfv:",rest"`
+	}
+
+	type dictRest struct {
+		_    struct{}       `sfv:",dictionary"`
+		Rest sfv.Dictionary `sfv:",rest"`
+	}
+
+	testCases := []struct {
+		In   string
+		Out  interface{}
+		Fail bool
+	}{
+		{In: "x;a=1", Out: new(item), Fail: false},
+		{In: "x;a=1;b=2", Out: new(item), Fail: true},
+		{In: "x;b=2", Out: new(sfv.Token), Fail: true},
+		{In: "x, y;b=2", Out: new([]sfv.Token), Fail: true},
+		{In: "(x y);a=1", Out: new([]innerList), Fail: false},
+		{In: "(x y);b=1", Out: new([]innerList), Fail: true},
+		{In: "(x y);b=1", Out: new([][]sfv.Token), Fail: true},
+		{In: "(x y;b=1)", Out: new([][]sfv.Token), Fail: true},
+		{In: "k=x;b=1", Out: new(map[string]sfv.Token), Fail: true},
+		{In: "k=x", Out: new(map[string]sfv.Token), Fail: false},
+		{In: "a=1", Out: new(dict), Fail: false},
+		{In: "a=1, b=2", Out: new(dict), Fail: true},
+		{In: "x;a=1;b=2", Out: new(itemRest), Fail: false},
+		{In: "a=1, b=2", Out: new(dictRest), Fail: false},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%T %s", tt.Out, tt.In), func(t *testing.T) {
+			// Without DisallowUnknownKeys, unknown keys are always ignored.
+			var lax sfv.Decoder
+			if err := lax.Unmarshal(tt.In, tt.Out); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			d := sfv.Decoder{DisallowUnknownKeys: true}
+			err := d.Unmarshal(tt.In, tt.Out)
+
+			if tt.Fail && !errors.Is(err, sfv.ErrUnknownKey) {
+				t.Errorf("bad err: want: %v, got: %v", sfv.ErrUnknownKey, err)
+			}
+
+			if !tt.Fail && err != nil {
+				t.Errorf("err: %v", err)
+			}
+		})
+	}
+}
+
+func TestUnmarshalLines(t *testing.T) {
+	testCases := []struct {
+		In     []string
+		Out    []sfv.Token
+		Err    bool
+		Line   int
+		Offset int
+	}{
+		{In: []string{}, Out: nil},
+		{In: []string{"a"}, Out: []sfv.Token{"a"}},
+		{In: []string{"a, b", "c"}, Out: []sfv.Token{"a", "b", "c"}},
+		{In: []string{"a", "", "  ", "b"}, Out: []sfv.Token{"a", "b"}},
+		{In: []string{"", "a"}, Out: []sfv.Token{"a"}},
+		{In: []string{"a", "b;"}, Err: true, Line: 1, Offset: 2},
+		{In: []string{"a", "", "b c"}, Err: true, Line: 2, Offset: 2},
+		{In: []string{"a;", "b"}, Err: true, Line: 0, Offset: 2},
+		{In: []string{"a, b", "c", "?2"}, Err: true, Line: 2, Offset: 1},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%q", tt.In), func(t *testing.T) {
+			var out []sfv.Token
+			err := sfv.UnmarshalLines(tt.In, &out)
+
+			if !tt.Err {
+				if err != nil {
+					t.Errorf("err: %v", err)
+					return
+				}
+
+				if !reflect.DeepEqual(tt.Out, out) {
+					t.Errorf("bad unmarshal result: want: %#v, got: %#v", tt.Out, out)
+				}
+
+				return
+			}
+
+			var pe *sfv.ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("bad err: want ParseError, got: %#v", err)
+				return
+			}
+
+			if pe.Line != tt.Line || pe.Offset != tt.Offset {
+				t.Errorf("bad err position: want: %d:%d, got: %d:%d", tt.Line, tt.Offset, pe.Line, pe.Offset)
+			}
+		})
+	}
+}
+
+func ExampleParseError_Format() {
+	var list []string
+	err := sfv.Unmarshal("gzip, br, ]deflate", &list)
+
+	fmt.Println(err)
+	fmt.Printf("%+v\n", err)
+
+	// Output:
+	// invalid start of bare item
+	// invalid start of bare item (expected bare item) at line 1, column 11:
+	//   gzip, br, ]deflate
+	//             ^
+}
+
+func TestUnmarshal_parse_errors(t *testing.T) {
+	testCases := []struct {
+		In       string
+		Offset   int
+		Kind     error
+		Expected string
+	}{
+		{In: "]", Offset: 0, Kind: sfv.ErrSyntax, Expected: "bare item"},
+		{In: "a b", Offset: 2, Kind: sfv.ErrSyntax, Expected: "','"},
+		{In: "a,", Offset: 2, Kind: sfv.ErrSyntax, Expected: "list member"},
+		{In: "-", Offset: 1, Kind: sfv.ErrUnexpectedEOF},
+		{In: "-a", Offset: 1, Kind: sfv.ErrSyntax, Expected: "digit"},
+		{In: "?2", Offset: 1, Kind: sfv.ErrSyntax, Expected: "'0' or '1'"},
+		{In: `"a\b"`, Offset: 3, Kind: sfv.ErrInvalidString, Expected: `'\' or '"'`},
+		{In: ":AQ!D:", Offset: 3, Kind: sfv.ErrInvalidBinary, Expected: "base64"},
+		{In: "(a b", Offset: 4, Kind: sfv.ErrUnexpectedEOF},
+		{In: "(a,b)", Offset: 2, Kind: sfv.ErrSyntax, Expected: "' ' or ')'"},
+		{In: "a;B", Offset: 2, Kind: sfv.ErrInvalidKey, Expected: "key"},
+		{In: `%"%AA"`, Offset: 3, Kind: sfv.ErrInvalidString, Expected: "lowercase hex digit"},
+		{In: "@1.5", Offset: 1, Kind: sfv.ErrSyntax, Expected: "integer"},
+		{In: "a, b c", Offset: 5, Kind: sfv.ErrSyntax, Expected: "','"},
+		{In: "1234567890123456", Offset: 15, Kind: sfv.ErrIntegerRange, Expected: "at most 15 digits"},
+		{In: "1234567890123.4", Offset: 13, Kind: sfv.ErrDecimalRange, Expected: "at most 12 integer digits"},
+		{In: "1.2345", Offset: 5, Kind: sfv.ErrDecimalRange, Expected: "at most 3 fractional digits"},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			var list []sfv.Member
+			err := sfv.Unmarshal(tt.In, &list)
+
+			var pe *sfv.ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("bad err: want ParseError, got: %#v", err)
+				return
+			}
+
+			if !errors.Is(err, tt.Kind) {
+				t.Errorf("bad err kind: want: %v, got: %v", tt.Kind, pe.Err)
+			}
+
+			if pe.Offset != tt.Offset || pe.Expected != tt.Expected || pe.Input != tt.In {
+				t.Errorf("bad err: want: %d %q %q, got: %d %q %q", tt.Offset, tt.Expected, tt.In, pe.Offset, pe.Expected, pe.Input)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_integer_range(t *testing.T) {
+	testCases := []struct {
+		In   string
+		Out  interface{}
+		Want interface{}
+		Err  bool
+	}{
+		{In: "127", Out: new(int8), Want: int8(127)},
+		{In: "-128", Out: new(int8), Want: int8(-128)},
+		{In: "128", Out: new(int8), Err: true},
+		{In: "-129", Out: new(int8), Err: true},
+		{In: "300", Out: new(int8), Err: true},
+		{In: "32768", Out: new(int16), Err: true},
+		{In: "2147483648", Out: new(int32), Err: true},
+		{In: "-999999999999999", Out: new(int64), Want: int64(-999999999999999)},
+		{In: "999999999999999", Out: new(int), Want: 999999999999999},
+		{In: "255", Out: new(uint8), Want: uint8(255)},
+		{In: "256", Out: new(uint8), Err: true},
+		{In: "65536", Out: new(uint16), Err: true},
+		{In: "4294967296", Out: new(uint32), Err: true},
+		{In: "0", Out: new(uint), Want: uint(0)},
+		{In: "-1", Out: new(uint), Err: true},
+		{In: "-1", Out: new(uint64), Err: true},
+		{In: "999999999999999", Out: new(uint64), Want: uint64(999999999999999)},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%T %s", tt.Out, tt.In), func(t *testing.T) {
+			err := sfv.Unmarshal(tt.In, tt.Out)
+			if tt.Err {
+				if !errors.Is(err, sfv.ErrIntegerRange) {
+					t.Errorf("bad err: want: %v, got: %v", sfv.ErrIntegerRange, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if got := reflect.ValueOf(tt.Out).Elem().Interface(); got != tt.Want {
+				t.Errorf("bad value: want: %v, got: %v", tt.Want, got)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_tag_options(t *testing.T) {
+	type data struct {
+		Value sfv.Token
+		Str   string  `sfv:"s,string"`
+		Req   int     `sfv:"r,required"`
+		Def   float64 `sfv:"d,default=1.5"`
+		Comma string  `sfv:"c,string,default=\"a, b\""`
+	}
+
+	testCases := []struct {
+		In   string
+		Out  data
+		Kind error
+	}{
+		{In: "x;r=1", Out: data{Value: "x", Req: 1, Def: 1.5, Comma: "a, b"}},
+		{In: `x;r=1;d=2.5;c="c";s="s"`, Out: data{Value: "x", Req: 1, Def: 2.5, Comma: "c", Str: "s"}},
+		{In: "x", Kind: sfv.ErrMissingParameter},
+		{In: "x;r=1;s=s", Kind: sfv.ErrTypeMismatch},
+		{In: "x;r=1;d=1", Kind: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			var out data
+			err := sfv.Unmarshal(tt.In, &out)
+
+			if tt.Kind != nil {
+				if !errors.Is(err, tt.Kind) {
+					t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_invalid_default(t *testing.T) {
+	var out struct {
+		Value sfv.Token
+		Def   int `sfv:"d,default=1 2"`
+	}
+
+	if err := sfv.Unmarshal("x", &out); !errors.Is(err, sfv.ErrTrailingCharacters) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrTrailingCharacters, err)
+	}
+
+	// A default must be the type of bare item its field is serialized as, or
+	// else the field couldn't be serialized as it was parsed.
+	type item struct {
+		Value sfv.Token
+		C     string `sfv:"c,default=\"x,y\""`
+	}
+
+	var data item
+	if err := sfv.Unmarshal("v", &data); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if out, err := sfv.Marshal(data); err != nil || out != `v;c="x,y"` {
+		t.Errorf("bad round-trip: %q (err: %v)", out, err)
+	}
+
+	d := sfv.Decoder{TokenStrings: true}
+	if err := d.Unmarshal("v", &data); !errors.Is(err, sfv.ErrTypeMismatch) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrTypeMismatch, err)
+	}
+
+	var dict struct {
+		_ struct{} `sfv:",dictionary"`
+		D string   `sfv:"d,default=\"x\""`
+	}
+
+	if err := d.Unmarshal("", &dict); !errors.Is(err, sfv.ErrTypeMismatch) {
+		t.Errorf("bad err: want: %v, got: %v", sfv.ErrTypeMismatch, err)
+	}
+}
+
+func TestUnmarshal_dict_struct(t *testing.T) {
+	type lang struct {
+		Tags []sfv.Token
+		Q    float64 `sfv:"q"`
+	}
+
+	type data struct {
+		_      struct{}  `sfv:",dictionary"`
+		Int    int       `sfv:"int"`
+		Str    string    `sfv:"str,string"`
+		Bytes  []byte    `sfv:"bytes"`
+		Item   mediaType `sfv:"item"`
+		Inner  []int     `sfv:"inner"`
+		Params lang      `sfv:"params"`
+		Req    bool      `sfv:"req,required"`
+		Def    sfv.Token `sfv:"def,default=foo"`
+		Skip   int
+	}
+
+	testCases := []struct {
+		In   string
+		Out  data
+		Kind error
+	}{
+		{
+			In: `int=1, str="a", bytes=:AQID:, item="text/html", inner=(1 2), params=(en fr);q=0.5, req`,
+			Out: data{
+				Int:    1,
+				Str:    "a",
+				Bytes:  []byte{1, 2, 3},
+				Item:   mediaType{Type: "text", Subtype: "html"},
+				Inner:  []int{1, 2},
+				Params: lang{Tags: []sfv.Token{"en", "fr"}, Q: 0.5},
+				Req:    true,
+				Def:    "foo",
+			},
+		},
+		{In: "req, def=bar", Out: data{Req: true, Def: "bar"}},
+		{In: "int=1", Kind: sfv.ErrMissingParameter},
+		{In: "req, int=a", Kind: sfv.ErrTypeMismatch},
+		{In: "req, str=a", Kind: sfv.ErrTypeMismatch},
+		{In: "req, inner=1", Kind: sfv.ErrTypeMismatch},
+		{In: "req, int=(1)", Kind: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			var out data
+			err := sfv.Unmarshal(tt.In, &out)
+
+			if tt.Kind != nil {
+				if !errors.Is(err, tt.Kind) {
+					t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out, tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_embedded(t *testing.T) {
+	type Trace struct {
+		ID   sfv.Token `sfv:"trace"`
+		Span sfv.Token `sfv:"span"`
+	}
+
+	type Rest struct {
+		Rest sfv.Params `sfv:",rest"`
+	}
+
+	type embedded struct {
+		Trace
+		Value sfv.Token
+	}
+
+	type embeddedPtr struct {
+		*Trace
+		Value sfv.Token
+	}
+
+	type shadowed struct {
+		Trace
+		Value sfv.Token
+		Span  int `sfv:"span"`
+	}
+
+	type explicit struct {
+		Trace
+		A     sfv.Token `sfv:"a"`
+		B     sfv.Token
+		Value []int `sfv:",value"`
+	}
+
+	type embeddedRest struct {
+		Rest
+		Value sfv.Token
+	}
+
+	type dict struct {
+		_ struct{} `sfv:",dictionary"`
+		Trace
+		A int `sfv:"a"`
+	}
+
+	type trace struct {
+		ID sfv.Token `sfv:"trace"`
+	}
+
+	// Unexported embedded structs are promoted, but unexported embedded
+	// pointers can't be allocated, and so are ignored like other unexported
+	// fields.
+	type unexported struct {
+		trace
+		Value sfv.Token
+	}
+
+	type unexportedPtr struct {
+		*trace
+		Value sfv.Token
+	}
+
+	type unexportedTagged struct {
+		Value sfv.Token
+		a     int `sfv:"a"`
+	}
+
+	testCases := []struct {
+		In  string
+		Out interface{}
+	}{
+		{In: "x;trace=a", Out: unexported{trace: trace{ID: "a"}, Value: "x"}},
+		{In: "x;trace=a", Out: unexportedPtr{Value: "x"}},
+		{In: "x;a=1", Out: unexportedTagged{Value: "x"}},
+		{In: "x;trace=a;span=b", Out: embedded{Trace: Trace{ID: "a", Span: "b"}, Value: "x"}},
+		{In: "x;trace=a", Out: embeddedPtr{Trace: &Trace{ID: "a"}, Value: "x"}},
+		{In: "x;span=1", Out: shadowed{Value: "x", Span: 1}},
+		{In: "(1 2);trace=a;a=b", Out: []explicit{{Trace: Trace{ID: "a"}, A: "b", Value: []int{1, 2}}}},
+		{In: "x;a=1;b=2", Out: embeddedRest{Rest: Rest{Rest: sfv.Params{
+			Map: map[string]sfv.BareItem{
+				"a": {Type: sfv.BareItemTypeInteger, Integer: 1},
+				"b": {Type: sfv.BareItemTypeInteger, Integer: 2},
+			},
+			Keys: []string{"a", "b"},
+		}}, Value: "x"}},
+		{In: "a=1, trace=x, span=y", Out: dict{Trace: Trace{ID: "x", Span: "y"}, A: 1}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			out := reflect.New(reflect.TypeOf(tt.Out))
+			if err := sfv.Unmarshal(tt.In, out.Interface()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out.Elem().Interface(), tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out.Elem().Interface())
+			}
+		})
+	}
+
+	// Pointers to embedded structs are only allocated if one of their fields
+	// is present.
+	var out embeddedPtr
+	if err := sfv.Unmarshal("x", &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	if out.Trace != nil {
+		t.Errorf("embedded pointer allocated: %#v", out.Trace)
+	}
+}
+
+func TestUnmarshal_rest(t *testing.T) {
+	t.Run("inner list", func(t *testing.T) {
+		type data struct {
+			Items []sfv.Token
+			A     int        `sfv:"a"`
+			Rest  sfv.Params `sfv:",rest"`
+		}
+
+		var out []data
+		if err := sfv.Unmarshal("(x y);b=2;a=1;c", &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		want := []data{{
+			Items: []sfv.Token{"x", "y"},
+			A:     1,
+			Rest: sfv.Params{
+				Map: map[string]sfv.BareItem{
+					"b": {Type: sfv.BareItemTypeInteger, Integer: 2},
+					"c": {Type: sfv.BareItemTypeBoolean, Boolean: true},
+				},
+				Keys: []string{"b", "c"},
+			},
+		}}
+
+		if !reflect.DeepEqual(out, want) {
+			t.Errorf("actual != expected: want: %#v, got: %#v", want, out)
+		}
+	})
+
+	t.Run("dictionary", func(t *testing.T) {
+		type data struct {
+			_    struct{}       `sfv:",dictionary"`
+			A    int            `sfv:"a"`
+			Rest sfv.Dictionary `sfv:",rest"`
+		}
+
+		var out data
+		if err := sfv.Unmarshal("b=(1 2), a=1, c", &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if out.A != 1 || !reflect.DeepEqual(out.Rest.Keys, []string{"b", "c"}) {
+			t.Errorf("bad result: %#v", out)
+		}
+	})
+
+	t.Run("no unknown keys", func(t *testing.T) {
+		var out struct {
+			Value sfv.Token
+			A     int        `sfv:"a"`
+			Rest  sfv.Params `sfv:",rest"`
+		}
+
+		if err := sfv.Unmarshal("x;a=1", &out); err != nil {
+			t.Fatalf("err: %v", err)
+		}
+
+		if out.Value != "x" || out.A != 1 || out.Rest.Keys != nil {
+			t.Errorf("bad result: %#v", out)
+		}
+	})
+
+	t.Run("wrong type", func(t *testing.T) {
+		var out struct {
+			Value sfv.Token
+			Rest  map[string]int `sfv:",rest"`
+		}
+
+		if err := sfv.Unmarshal("x;a=1", &out); !errors.Is(err, sfv.ErrTypeMismatch) {
+			t.Errorf("bad err: want: %v, got: %v", sfv.ErrTypeMismatch, err)
+		}
+	})
+}
+
+func TestUnmarshal_pointers(t *testing.T) {
+	type lang struct {
+		Tags []sfv.Token
+		Q    *float64 `sfv:"q"`
+	}
+
+	type item struct {
+		Value *sfv.Token
+		Int   *int       `sfv:"int"`
+		Bool  *bool      `sfv:"bool"`
+		Str   *sfv.Token `sfv:"str"`
+		Bytes *[]byte    `sfv:"bytes"`
+	}
+
+	type dict struct {
+		_      struct{} `sfv:",dictionary"`
+		Int    *int     `sfv:"int"`
+		Lang   *lang    `sfv:"lang"`
+		Item   *item    `sfv:"item"`
+		Absent *item    `sfv:"absent"`
+	}
+
+	intp := func(i int) *int { return &i }
+	boolp := func(b bool) *bool { return &b }
+	tokenp := func(s sfv.Token) *sfv.Token { return &s }
+	floatp := func(f float64) *float64 { return &f }
+
+	testCases := []struct {
+		In  string
+		Out interface{}
+	}{
+		{In: "x;int=0;bool=?0", Out: item{Value: tokenp("x"), Int: intp(0), Bool: boolp(false)}},
+		{In: "x;str=a;bytes=::", Out: item{Value: tokenp("x"), Str: tokenp("a"), Bytes: &[]byte{}}},
+		{In: "x", Out: item{Value: tokenp("x")}},
+		{In: "1, 2", Out: []*int{intp(1), intp(2)}},
+		{In: "(a b);q=0.0", Out: []*lang{{Tags: []sfv.Token{"a", "b"}, Q: floatp(0)}}},
+		{In: "int=0, lang=(a);q=1.0, item=x;int=1", Out: dict{
+			Int:  intp(0),
+			Lang: &lang{Tags: []sfv.Token{"a"}, Q: floatp(1)},
+			Item: &item{Value: tokenp("x"), Int: intp(1)},
+		}},
+		{In: "", Out: dict{}},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			out := reflect.New(reflect.TypeOf(tt.Out))
+			if err := sfv.Unmarshal(tt.In, out.Interface()); err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out.Elem().Interface(), tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out.Elem().Interface())
+			}
+		})
+	}
+}
+
+func ExampleUnmarshal_custom_item_durations() {
+	type cacheStatus struct {
+		Cache   sfv.Token
+		TTL     time.Duration `sfv:"ttl"`
+		Latency time.Duration `sfv:"latency,ms"`
+		Stored  time.Time     `sfv:"stored,unix"`
+	}
+
+	var data cacheStatus
+	fmt.Println(sfv.Unmarshal("ExampleCache;ttl=300;latency=0.25;stored=1659578233", &data))
+	fmt.Println(data.TTL, data.Latency, data.Stored)
+
+	// Output:
+	// <nil>
+	// 5m0s 250ms 2022-08-04 01:57:13 +0000 UTC
+}
+
+func TestUnmarshal_time_options(t *testing.T) {
+	type item struct {
+		Value    sfv.Token
+		Duration time.Duration `sfv:"d"`
+		Millis   time.Duration `sfv:"ms,ms"`
+		Date     time.Time     `sfv:"t"`
+		Unix     time.Time     `sfv:"u,unix"`
+	}
+
+	testCases := []struct {
+		In   string
+		Out  item
+		Kind error
+	}{
+		{In: "a;d=-60;ms=1.5;t=@1;u=2", Out: item{Value: "a", Duration: -time.Minute, Millis: 1500 * time.Millisecond, Date: time.Unix(1, 0).UTC(), Unix: time.Unix(2, 0).UTC()}},
+		{In: "a;d=9223372036", Out: item{Value: "a", Duration: 9223372036 * time.Second}},
+		{In: "a;d=9223372037", Kind: sfv.ErrIntegerRange},
+		{In: "a;ms=9223372036854.775", Kind: sfv.ErrDecimalRange},
+		{In: "a;d=1.5", Kind: sfv.ErrTypeMismatch},
+		{In: "a;ms=1", Kind: sfv.ErrTypeMismatch},
+		{In: "a;t=1", Kind: sfv.ErrTypeMismatch},
+		{In: "a;u=@1", Kind: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.In, func(t *testing.T) {
+			var out item
+			err := sfv.Unmarshal(tt.In, &out)
+			if tt.Kind != nil {
+				if !errors.Is(err, tt.Kind) {
+					t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out, tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out)
+			}
+		})
+	}
+
+	var d time.Duration
+	if err := sfv.Unmarshal("5", &d); err != nil || d != 5*time.Second {
+		t.Errorf("bad duration: %v (err: %v)", d, err)
+	}
+}
+
+func ExampleToken() {
+	type cacheStatus struct {
+		Cache  sfv.Token
+		Detail string `sfv:"detail"`
+	}
+
+	var data cacheStatus
+	fmt.Println(sfv.Unmarshal(`ExampleCache;detail="hello world"`, &data))
+	fmt.Println(data.Cache, data.Detail)
+	fmt.Println(sfv.Marshal(data))
+
+	// Output:
+	// <nil>
+	// ExampleCache hello world
+	// ExampleCache;detail="hello world" <nil>
+}
+
+func TestDecoder_TokenStrings(t *testing.T) {
+	type item struct {
+		Value   string
+		Token   sfv.Token         `sfv:"t"`
+		Display sfv.DisplayString `sfv:"d"`
+		Text    mediaType         `sfv:"m"`
+	}
+
+	testCases := []struct {
+		In           string
+		TokenStrings bool
+		Out          item
+		Err          error
+	}{
+		{In: `a;t=b;d=%"c";m=text/html`, TokenStrings: true, Out: item{Value: "a", Token: "b", Display: "c", Text: mediaType{"text", "html"}}},
+		{In: `"a";t=b;d=%"c";m="text/html"`, TokenStrings: true, Out: item{Value: "a", Token: "b", Display: "c", Text: mediaType{"text", "html"}}},
+		{In: `"a";t=b;d=%"c";m="text/html"`, Out: item{Value: "a", Token: "b", Display: "c", Text: mediaType{"text", "html"}}},
+		{In: `a`, Err: sfv.ErrTypeMismatch},
+		{In: `"a";t="b"`, TokenStrings: true, Err: sfv.ErrTypeMismatch},
+		{In: `"a";d="c"`, TokenStrings: true, Err: sfv.ErrTypeMismatch},
+		{In: `"a";m=text/html`, Err: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%s %v", tt.In, tt.TokenStrings), func(t *testing.T) {
+			d := sfv.Decoder{TokenStrings: tt.TokenStrings}
+
+			var out item
+			err := d.Unmarshal(tt.In, &out)
+			if tt.Err != nil {
+				if !errors.Is(err, tt.Err) {
+					t.Errorf("bad err: want: %v, got: %v", tt.Err, err)
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if !reflect.DeepEqual(out, tt.Out) {
+				t.Errorf("actual != expected: want: %#v, got: %#v", tt.Out, out)
+			}
+		})
+	}
+}
+
+func TestUnmarshal_named_map_keys(t *testing.T) {
+	var out map[sfv.Token]int
+	if err := sfv.Unmarshal("a=1, b=2", &out); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	want := map[sfv.Token]int{"a": 1, "b": 2}
+	if !reflect.DeepEqual(out, want) {
+		t.Errorf("actual != expected: want: %#v, got: %#v", want, out)
+	}
+}
+
+func TestUnmarshal_empty_params(t *testing.T) {
+	// Items and inner lists without parameters still get a Params.Map, so it
+	// can be assigned to directly.
+	var list sfv.List
+	if err := sfv.Unmarshal("a, (b)", &list); err != nil {
+		t.Fatalf("err: %v", err)
+	}
+
+	list[0].Item.Params.Map["x"] = sfv.NewInteger(1)
+	list[1].InnerList.Params.Map["x"] = sfv.NewInteger(1)
+	list[1].InnerList.Items[0].Params.Map["x"] = sfv.NewInteger(1)
+}
+
+func TestUnmarshal_error_kinds(t *testing.T) {
+	testCases := []struct {
+		In   string
+		Out  interface{}
+		Kind error
+	}{
+		{In: "a b", Out: new(sfv.Item), Kind: sfv.ErrTrailingCharacters},
+		{In: "a", Out: new(chan int), Kind: sfv.ErrUnsupportedType},
+		{In: "a", Out: new(int), Kind: sfv.ErrTypeMismatch},
+		{In: "a, 1", Out: new([]string), Kind: sfv.ErrTypeMismatch},
+		{In: "a=1", Out: new(map[int]int), Kind: sfv.ErrTypeMismatch},
+		{In: "a;q=1", Out: new(struct {
+			A sfv.Token
+			Q sfv.Token `sfv:"q"`
+		}), Kind: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%T %s", tt.Out, tt.In), func(t *testing.T) {
+			if err := sfv.Unmarshal(tt.In, tt.Out); !errors.Is(err, tt.Kind) {
+				t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+			}
+		})
+	}
+}
+
+func ExampleBindError() {
+	type priority struct {
+		_           struct{} `sfv:",dictionary"`
+		Urgency     int      `sfv:"u"`
+		Incremental bool     `sfv:"i"`
+	}
+
+	var data priority
+	err := sfv.Unmarshal("u=high, i", &data)
+
+	var bindErr *sfv.BindError
+	fmt.Println(errors.As(err, &bindErr))
+	fmt.Println(bindErr.Path, bindErr.Field)
+	fmt.Println(err)
+
+	// Output:
+	// true
+	// dict["u"] Urgency
+	// dict["u"] (field Urgency): sfv: type mismatch: cannot unmarshal token into int
+}
+
+func TestUnmarshal_bind_error(t *testing.T) {
+	type lang struct {
+		Tags []sfv.Token
+		Q    float64 `sfv:"q,default=1.0"`
+	}
+
+	type item struct {
+		Value sfv.Token
+		Int   int `sfv:"int"`
+	}
+
+	type dict struct {
+		_    struct{} `sfv:",dictionary"`
+		Req  int      `sfv:"req,required"`
+		Lang *lang    `sfv:"lang"`
+		Item item     `sfv:"item"`
+	}
+
+	testCases := []struct {
+		In    string
+		Out   interface{}
+		Path  string
+		Field string
+		Kind  error
+	}{
+		{In: "a", Out: new(int), Path: "item", Kind: sfv.ErrTypeMismatch},
+		{In: "1", Out: new(item), Path: "item", Field: "Value", Kind: sfv.ErrTypeMismatch},
+		{In: "a;int=b", Out: new(item), Path: `item.params["int"]`, Field: "Int", Kind: sfv.ErrTypeMismatch},
+		{In: "1, a", Out: new([]int), Path: "list[1]", Kind: sfv.ErrTypeMismatch},
+		{In: "(1), (1 a)", Out: new([][]int), Path: "list[1][1]", Kind: sfv.ErrTypeMismatch},
+		{In: "(a 1)", Out: new([]lang), Path: "list[0][1]", Field: "Tags", Kind: sfv.ErrTypeMismatch},
+		{In: "(a);q=b", Out: new([]lang), Path: `list[0].params["q"]`, Field: "Q", Kind: sfv.ErrTypeMismatch},
+		{In: "a=1, b=x", Out: new(map[string]int), Path: `dict["b"]`, Kind: sfv.ErrTypeMismatch},
+		{In: "lang=(a)", Out: new(dict), Path: `dict["req"]`, Field: "Req", Kind: sfv.ErrMissingParameter},
+		{In: "req=1, lang=(a);q=1", Out: new(dict), Path: `dict["lang"].params["q"]`, Field: "Lang.Q", Kind: sfv.ErrTypeMismatch},
+		{In: "req=1, item=a;int=?1", Out: new(dict), Path: `dict["item"].params["int"]`, Field: "Item.Int", Kind: sfv.ErrTypeMismatch},
+		{In: "req=1, item=1", Out: new(dict), Path: `dict["item"]`, Field: "Item.Value", Kind: sfv.ErrTypeMismatch},
+	}
+
+	for _, tt := range testCases {
+		t.Run(fmt.Sprintf("%T %s", tt.Out, tt.In), func(t *testing.T) {
+			err := sfv.Unmarshal(tt.In, tt.Out)
+
+			var bindErr *sfv.BindError
+			if !errors.As(err, &bindErr) {
+				t.Fatalf("bad err: want: *sfv.BindError, got: %#v", err)
+			}
+
+			if bindErr.Path != tt.Path {
+				t.Errorf("bad path: want: %s, got: %s", tt.Path, bindErr.Path)
+			}
+
+			if bindErr.Field != tt.Field {
+				t.Errorf("bad field: want: %q, got: %q", tt.Field, bindErr.Field)
+			}
+
+			if !errors.Is(err, tt.Kind) {
+				t.Errorf("bad err: want: %v, got: %v", tt.Kind, err)
+			}
+		})
+	}
+}
+
+func benchmarkUnmarshalInputs() []struct {
+	Name  string
+	Input string
+	New   func() interface{}
+} {
+	type contentType struct {
+		MediaType sfv.Token
+		Charset   sfv.Token `sfv:"charset"`
+	}
+
+	return []struct {
+		Name  string
+		Input string
+		New   func() interface{}
+	}{
+		{
+			Name:  "item",
+			Input: "text/html;charset=utf-8;q=0.9",
+			New:   func() interface{} { return &sfv.Item{} },
+		},
+		{
+			Name:  "list",
+			Input: `gzip;q=1.0, br;q=0.9, ("a" "b");x=:AQIDBA==:, *;q=0.1`,
+			New:   func() interface{} { return &[]sfv.Member{} },
+		},
+		{
+			Name:  "dict",
+			Input: "u=3, i, max-age=604800, sig=:AQIDBA==:",
+			New:   func() interface{} { return &sfv.Dictionary{} },
+		},
+		{
+			Name:  "struct",
+			Input: "text/html;charset=utf-8",
+			New:   func() interface{} { return &contentType{} },
+		},
+		{
+			Name:  "map",
+			Input: "u=3, i=1",
+			New:   func() interface{} { return &map[string]int{} },
+		},
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	for _, bb := range benchmarkUnmarshalInputs() {
+		b.Run(bb.Name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := sfv.Unmarshal(bb.Input, bb.New()); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,312 @@
+package sfv
+
+import "time"
+
+// NewInteger returns an integer bare item.
+func NewInteger(n int64) BareItem {
+	return BareItem{Type: BareItemTypeInteger, Integer: n}
+}
+
+// NewDecimal returns a decimal bare item.
+func NewDecimal(d Decimal) BareItem {
+	return BareItem{Type: BareItemTypeDecimal, Decimal: d}
+}
+
+// NewString returns a string bare item.
+func NewString(s string) BareItem {
+	return BareItem{Type: BareItemTypeString, String: s}
+}
+
+// NewToken returns a token bare item.
+func NewToken(s string) BareItem {
+	return BareItem{Type: BareItemTypeToken, Token: s}
+}
+
+// NewBinary returns a byte sequence bare item.
+func NewBinary(b []byte) BareItem {
+	return BareItem{Type: BareItemTypeBinary, Binary: b}
+}
+
+// NewBoolean returns a boolean bare item.
+func NewBoolean(b bool) BareItem {
+	return BareItem{Type: BareItemTypeBoolean, Boolean: b}
+}
+
+// NewDate returns a date bare item. Dates have a precision of one second, so t
+// is truncated to a whole number of seconds.
+func NewDate(t time.Time) BareItem {
+	return BareItem{Type: BareItemTypeDate, Date: t.Unix()}
+}
+
+// NewDisplayString returns a display string bare item.
+func NewDisplayString(s string) BareItem {
+	return BareItem{Type: BareItemTypeDisplayString, DisplayString: s}
+}
+
+// NewItem returns an item holding b, without any parameters. Use Params.Set on
+// the result to add parameters.
+func NewItem(b BareItem) Item {
+	return Item{BareItem: b}
+}
+
+// NewInnerList returns an inner list holding items, without any parameters.
+func NewInnerList(items ...Item) InnerList {
+	return InnerList{Items: items}
+}
+
+// NewItemMember returns a list or dictionary member holding i.
+func NewItemMember(i Item) Member {
+	return Member{IsItem: true, Item: i}
+}
+
+// NewInnerListMember returns a list or dictionary member holding l.
+func NewInnerListMember(l InnerList) Member {
+	return Member{InnerList: l}
+}
+
+// Len returns the number of members in d.
+func (d Dictionary) Len() int {
+	return len(d.Keys)
+}
+
+// Has returns whether d has a member called key.
+func (d Dictionary) Has(key string) bool {
+	_, ok := d.Map[key]
+	return ok
+}
+
+// Get returns the member of d called key, and whether there is one.
+func (d Dictionary) Get(key string) (Member, bool) {
+	m, ok := d.Map[key]
+	return m, ok
+}
+
+// Set sets the member of d called key to m. If d already has a member called
+// key, it keeps its position. Otherwise, m is added after all of the other
+// members.
+//
+// Set doesn't check that key is valid. Marshal fails with ErrInvalidKey if it
+// isn't, for instance if it's empty.
+func (d *Dictionary) Set(key string, m Member) {
+	if d.Map == nil {
+		d.Map = map[string]Member{}
+	}
+
+	if _, ok := d.Map[key]; !ok {
+		d.Keys = append(d.Keys, key)
+	}
+
+	d.Map[key] = m
+}
+
+// InsertAfter sets the member of d called key to m, and moves it to just after
+// the member called after. It returns false, and leaves d unchanged, if d has
+// no member called after. Like Set, it doesn't check that key is valid.
+func (d *Dictionary) InsertAfter(after, key string, m Member) bool {
+	if !d.Has(after) {
+		return false
+	}
+
+	d.Map[key] = m
+	d.Keys = insertKeyAfter(d.Keys, after, key)
+	return true
+}
+
+// Delete removes the member of d called key, if there is one.
+func (d *Dictionary) Delete(key string) {
+	if _, ok := d.Map[key]; ok {
+		delete(d.Map, key)
+		d.Keys = removeKey(d.Keys, key)
+	}
+}
+
+// Range calls f on each member of d, in order. If f returns false, Range stops
+// early.
+func (d Dictionary) Range(f func(key string, m Member) bool) {
+	for _, k := range d.Keys {
+		if !f(k, d.Map[k]) {
+			return
+		}
+	}
+}
+
+// GetInt returns the integer held by the member of d called key. It returns
+// false if there is no such member, or it isn't an item holding an integer.
+func (d Dictionary) GetInt(key string) (int64, bool) {
+	b, ok := d.getBareItem(key, BareItemTypeInteger)
+	return b.Integer, ok
+}
+
+// GetDecimal returns the decimal held by the member of d called key. It returns
+// false if there is no such member, or it isn't an item holding a decimal.
+func (d Dictionary) GetDecimal(key string) (Decimal, bool) {
+	b, ok := d.getBareItem(key, BareItemTypeDecimal)
+	return b.Decimal, ok
+}
+
+// GetString returns the string held by the member of d called key. It returns
+// false if there is no such member, or it isn't an item holding a string.
+func (d Dictionary) GetString(key string) (string, bool) {
+	b, ok := d.getBareItem(key, BareItemTypeString)
+	return b.String, ok
+}
+
+// GetToken returns the token held by the member of d called key. It returns
+// false if there is no such member, or it isn't an item holding a token.
+func (d Dictionary) GetToken(key string) (string, bool) {
+	b, ok := d.getBareItem(key, BareItemTypeToken)
+	return b.Token, ok
+}
+
+// GetBool returns the boolean held by the member of d called key. It returns
+// false if there is no such member, or it isn't an item holding a boolean.
+func (d Dictionary) GetBool(key string) (value, ok bool) {
+	b, ok := d.getBareItem(key, BareItemTypeBoolean)
+	return b.Boolean, ok
+}
+
+func (d Dictionary) getBareItem(key string, t BareItemType) (BareItem, bool) {
+	m, ok := d.Map[key]
+	if !ok || !m.IsItem || m.Item.BareItem.Type != t {
+		return BareItem{}, false
+	}
+
+	return m.Item.BareItem, true
+}
+
+// Len returns the number of parameters in p.
+func (p Params) Len() int {
+	return len(p.Keys)
+}
+
+// Has returns whether p has a parameter called key.
+func (p Params) Has(key string) bool {
+	_, ok := p.Map[key]
+	return ok
+}
+
+// Get returns the value of the parameter called key, and whether there is one.
+func (p Params) Get(key string) (BareItem, bool) {
+	b, ok := p.Map[key]
+	return b, ok
+}
+
+// Set sets the value of the parameter called key to b. If p already has a
+// parameter called key, it keeps its position. Otherwise, it's added after all
+// of the other parameters.
+//
+// Set doesn't check that key is valid. Marshal fails with ErrInvalidKey if it
+// isn't, for instance if it's empty.
+func (p *Params) Set(key string, b BareItem) {
+	if p.Map == nil {
+		p.Map = map[string]BareItem{}
+	}
+
+	if _, ok := p.Map[key]; !ok {
+		p.Keys = append(p.Keys, key)
+	}
+
+	p.Map[key] = b
+}
+
+// InsertAfter sets the value of the parameter called key to b, and moves it to
+// just after the parameter called after. It returns false, and leaves p
+// unchanged, if p has no parameter called after. Like Set, it doesn't check
+// that key is valid.
+func (p *Params) InsertAfter(after, key string, b BareItem) bool {
+	if !p.Has(after) {
+		return false
+	}
+
+	p.Map[key] = b
+	p.Keys = insertKeyAfter(p.Keys, after, key)
+	return true
+}
+
+// Delete removes the parameter called key, if there is one.
+func (p *Params) Delete(key string) {
+	if _, ok := p.Map[key]; ok {
+		delete(p.Map, key)
+		p.Keys = removeKey(p.Keys, key)
+	}
+}
+
+// Range calls f on each parameter of p, in order. If f returns false, Range
+// stops early.
+func (p Params) Range(f func(key string, b BareItem) bool) {
+	for _, k := range p.Keys {
+		if !f(k, p.Map[k]) {
+			return
+		}
+	}
+}
+
+// GetInt returns the value of the parameter called key, if it's an integer.
+func (p Params) GetInt(key string) (int64, bool) {
+	b, ok := p.getBareItem(key, BareItemTypeInteger)
+	return b.Integer, ok
+}
+
+// GetDecimal returns the value of the parameter called key, if it's a decimal.
+func (p Params) GetDecimal(key string) (Decimal, bool) {
+	b, ok := p.getBareItem(key, BareItemTypeDecimal)
+	return b.Decimal, ok
+}
+
+// GetString returns the value of the parameter called key, if it's a string.
+func (p Params) GetString(key string) (string, bool) {
+	b, ok := p.getBareItem(key, BareItemTypeString)
+	return b.String, ok
+}
+
+// GetToken returns the value of the parameter called key, if it's a token.
+func (p Params) GetToken(key string) (string, bool) {
+	b, ok := p.getBareItem(key, BareItemTypeToken)
+	return b.Token, ok
+}
+
+// GetBool returns the value of the parameter called key, if it's a boolean.
+func (p Params) GetBool(key string) (value, ok bool) {
+	b, ok := p.getBareItem(key, BareItemTypeBoolean)
+	return b.Boolean, ok
+}
+
+func (p Params) getBareItem(key string, t BareItemType) (BareItem, bool) {
+	b, ok := p.Map[key]
+	if !ok || b.Type != t {
+		return BareItem{}, false
+	}
+
+	return b, true
+}
+
+// insertKeyAfter moves key to just after the key after in keys, adding it if
+// it isn't already present. after must be in keys.
+func insertKeyAfter(keys []string, after, key string) []string {
+	if key == after {
+		return keys
+	}
+
+	keys = removeKey(keys, key)
+	for i, k := range keys {
+		if k == after {
+			keys = append(keys, "")
+			copy(keys[i+2:], keys[i+1:])
+			keys[i+1] = key
+			break
+		}
+	}
+
+	return keys
+}
+
+// removeKey removes key from keys, if it's present.
+func removeKey(keys []string, key string) []string {
+	for i, k := range keys {
+		if k == key {
+			return append(keys[:i], keys[i+1:]...)
+		}
+	}
+
+	return keys
+}
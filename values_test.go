@@ -0,0 +1,214 @@
+package sfv_test
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ucarion/sfv"
+)
+
+func ExampleDictionary_Set() {
+	var dict sfv.Dictionary
+	dict.Set("public", sfv.NewItemMember(sfv.NewItem(sfv.NewBoolean(true))))
+	dict.Set("max-age", sfv.NewItemMember(sfv.NewItem(sfv.NewInteger(604800))))
+	dict.Set("immutable", sfv.NewItemMember(sfv.NewItem(sfv.NewBoolean(true))))
+
+	fmt.Println(sfv.Marshal(dict))
+
+	// Output: public, max-age=604800, immutable <nil>
+}
+
+func ExampleParams_Set() {
+	var item sfv.Item
+	fmt.Println(sfv.Unmarshal("text/html", &item))
+
+	item.Params.Set("charset", sfv.NewToken("utf-8"))
+	fmt.Println(sfv.Marshal(item))
+
+	// Output:
+	// <nil>
+	// text/html;charset=utf-8 <nil>
+}
+
+func ExampleParams_GetInt() {
+	var item sfv.Item
+	fmt.Println(sfv.Unmarshal("ExampleCache;hit;ttl=30", &item))
+	fmt.Println(item.Params.GetInt("ttl"))
+	fmt.Println(item.Params.GetBool("hit"))
+	fmt.Println(item.Params.GetToken("ttl"))
+
+	// Output:
+	// <nil>
+	// 30 true
+	// true true
+	//  false
+}
+
+func TestNewBareItem(t *testing.T) {
+	testCases := []struct {
+		In  sfv.BareItem
+		Out string
+	}{
+		{In: sfv.NewInteger(-1), Out: "-1"},
+		{In: sfv.NewDecimal(sfv.DecimalFromMilli(1500)), Out: "1.5"},
+		{In: sfv.NewString("a b"), Out: `"a b"`},
+		{In: sfv.NewToken("a/b"), Out: "a/b"},
+		{In: sfv.NewBinary([]byte{1}), Out: ":AQ==:"},
+		{In: sfv.NewBoolean(false), Out: "?0"},
+		{In: sfv.NewDate(time.Unix(1, 999)), Out: "@1"},
+		{In: sfv.NewDisplayString("ü"), Out: `%"%c3%bc"`},
+	}
+
+	for _, tt := range testCases {
+		t.Run(tt.Out, func(t *testing.T) {
+			out, err := sfv.Marshal(sfv.NewItem(tt.In))
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+
+			if out != tt.Out {
+				t.Errorf("actual != expected: want: %q, got: %q", tt.Out, out)
+			}
+		})
+	}
+
+	list := sfv.List{
+		sfv.NewInnerListMember(sfv.NewInnerList(sfv.NewItem(sfv.NewInteger(1)), sfv.NewItem(sfv.NewInteger(2)))),
+		sfv.NewItemMember(sfv.NewItem(sfv.NewToken("a"))),
+	}
+
+	if out, err := sfv.Marshal(list); err != nil || out != "(1 2), a" {
+		t.Errorf("bad list: %q (err: %v)", out, err)
+	}
+}
+
+func TestDictionary(t *testing.T) {
+	a := sfv.NewItemMember(sfv.NewItem(sfv.NewInteger(1)))
+	b := sfv.NewItemMember(sfv.NewItem(sfv.NewToken("b")))
+	c := sfv.NewInnerListMember(sfv.NewInnerList())
+
+	var dict sfv.Dictionary
+	dict.Set("a", a)
+	dict.Set("b", b)
+	dict.Set("a", b)
+
+	if dict.Len() != 2 || !reflect.DeepEqual(dict.Keys, []string{"a", "b"}) {
+		t.Errorf("bad keys after Set: %v", dict.Keys)
+	}
+
+	if m, ok := dict.Get("a"); !ok || !reflect.DeepEqual(m, b) {
+		t.Errorf("bad Get: %v %v", m, ok)
+	}
+
+	if !dict.InsertAfter("a", "c", c) || !reflect.DeepEqual(dict.Keys, []string{"a", "c", "b"}) {
+		t.Errorf("bad keys after InsertAfter: %v", dict.Keys)
+	}
+
+	if !dict.InsertAfter("b", "a", a) || !reflect.DeepEqual(dict.Keys, []string{"c", "b", "a"}) {
+		t.Errorf("bad keys after moving InsertAfter: %v", dict.Keys)
+	}
+
+	if dict.InsertAfter("x", "y", a) || dict.Has("y") {
+		t.Errorf("InsertAfter of missing key changed dict: %v", dict.Keys)
+	}
+
+	dict.Delete("b")
+	dict.Delete("x")
+	if dict.Has("b") || !reflect.DeepEqual(dict.Keys, []string{"c", "a"}) {
+		t.Errorf("bad keys after Delete: %v", dict.Keys)
+	}
+
+	var keys []string
+	dict.Range(func(k string, m sfv.Member) bool {
+		keys = append(keys, k)
+		return false
+	})
+
+	if !reflect.DeepEqual(keys, []string{"c"}) {
+		t.Errorf("Range didn't stop: %v", keys)
+	}
+
+	if n, ok := dict.GetInt("a"); !ok || n != 1 {
+		t.Errorf("bad GetInt: %v %v", n, ok)
+	}
+
+	if _, ok := dict.GetInt("c"); ok {
+		t.Errorf("GetInt on inner list succeeded")
+	}
+
+	if _, ok := dict.GetToken("a"); ok {
+		t.Errorf("GetToken on integer succeeded")
+	}
+
+	if out, err := sfv.Marshal(dict); err != nil || out != "c=(), a=1" {
+		t.Errorf("bad dict: %q (err: %v)", out, err)
+	}
+}
+
+func TestParams(t *testing.T) {
+	item := sfv.NewItem(sfv.NewToken("x"))
+	item.Params.Set("a", sfv.NewBoolean(true))
+	item.Params.Set("b", sfv.NewDecimal(sfv.DecimalFromMilli(500)))
+	item.Params.Set("c", sfv.NewString("s"))
+	item.Params.InsertAfter("a", "c", sfv.NewString("t"))
+
+	if out, err := sfv.Marshal(item); err != nil || out != `x;a;c="t";b=0.5` {
+		t.Errorf("bad item: %q (err: %v)", out, err)
+	}
+
+	if d, ok := item.Params.GetDecimal("b"); !ok || d.Milli() != 500 {
+		t.Errorf("bad GetDecimal: %v %v", d, ok)
+	}
+
+	if s, ok := item.Params.GetString("c"); !ok || s != "t" {
+		t.Errorf("bad GetString: %v %v", s, ok)
+	}
+
+	item.Params.Delete("a")
+	if item.Params.Len() != 2 || item.Params.Has("a") {
+		t.Errorf("bad params after Delete: %v", item.Params.Keys)
+	}
+}
+
+func TestMarshal_empty_key(t *testing.T) {
+	var dict sfv.Dictionary
+	dict.Set("", sfv.NewItemMember(sfv.NewItem(sfv.NewInteger(1))))
+
+	item := sfv.NewItem(sfv.NewToken("a"))
+	item.Params.Set("", sfv.NewInteger(1))
+
+	for _, in := range []interface{}{dict, item} {
+		if _, err := sfv.Marshal(in); !errors.Is(err, sfv.ErrInvalidKey) {
+			t.Errorf("bad err for %#v: want: %v, got: %v", in, sfv.ErrInvalidKey, err)
+		}
+	}
+}
+
+func TestMarshal_out_of_sync_keys(t *testing.T) {
+	one := sfv.NewItemMember(sfv.NewItem(sfv.NewInteger(1)))
+
+	// Large dictionaries are checked for repeated keys differently.
+	var large sfv.Dictionary
+	for i := 0; i < 40; i++ {
+		large.Set(fmt.Sprintf("k%d", i), one)
+	}
+
+	large.Keys[39] = "k0"
+
+	for _, in := range []interface{}{
+		large,
+		sfv.Dictionary{Keys: []string{"a", "b"}, Map: map[string]sfv.Member{"a": one, "c": one}},
+		sfv.Dictionary{Keys: []string{"a"}, Map: map[string]sfv.Member{"a": one, "b": one}},
+		sfv.Item{BareItem: sfv.NewToken("x"), Params: sfv.Params{Keys: []string{"a"}}},
+		sfv.Item{BareItem: sfv.NewToken("x"), Params: sfv.Params{Map: map[string]sfv.BareItem{"a": sfv.NewInteger(1)}}},
+		sfv.Dictionary{Keys: []string{"a", "a"}, Map: map[string]sfv.Member{"a": one, "b": one}},
+		sfv.Item{BareItem: sfv.NewToken("x"), Params: sfv.Params{Keys: []string{"a", "a"}, Map: map[string]sfv.BareItem{"a": sfv.NewInteger(1), "b": sfv.NewInteger(1)}}},
+	} {
+		if _, err := sfv.Marshal(in); !errors.Is(err, sfv.ErrInvalidKey) {
+			t.Errorf("bad err for %#v: want: %v, got: %v", in, sfv.ErrInvalidKey, err)
+		}
+	}
+}